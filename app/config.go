@@ -32,6 +32,62 @@ func LoadConfig(l *logrus.Logger) {
 
 	viper.SetDefault("mapDir", "maps")
 	viper.SetDefault("extDirs", "ext")
+	viper.SetDefault("seed", "18006665432")
+
+	viper.SetDefault("Terrain.Workers", 0)
+	viper.SetDefault("Terrain.Algorithm", "noise")
+	viper.SetDefault("Terrain.Roughness", 0.7)
+	viper.SetDefault("Terrain.Faults", 200)
+	viper.SetDefault("Terrain.Particles", 200000)
+	viper.SetDefault("Terrain.Drops", 20)
+	viper.SetDefault("Terrain.Exponent", 2.0)
+
+	viper.SetDefault("Terrain.Noise", "opensimplex")
+	viper.SetDefault("Terrain.Projection", "flat")
+	viper.SetDefault("Terrain.Stretch", -1.0/6)
+	viper.SetDefault("Terrain.Norm", 103.0)
+	viper.SetDefault("Terrain.Normalization", "raw")
+	viper.SetDefault("Terrain.Lattice", "table")
+	viper.SetDefault("Terrain.Worley.Metric", "euclidean")
+	viper.SetDefault("Terrain.Worley.Return", "f1")
+	viper.SetDefault("Terrain.Gabor.Orientation", 0.0)
+	viper.SetDefault("Terrain.Gabor.Frequency", 1.0)
+	viper.SetDefault("Terrain.Gabor.Bandwidth", 0.5)
+	viper.SetDefault("Terrain.Gabor.Impulses", 8)
+	viper.SetDefault("Terrain.Gabor.Steer", "")
+	viper.SetDefault("Terrain.Gabor.SteerFrequency", 0.1)
+
+	viper.SetDefault("Terrain.Fractal.Mode", "fbm")
+	viper.SetDefault("Terrain.Fractal.Octaves", 6)
+	viper.SetDefault("Terrain.Fractal.Lacunarity", 2.0)
+	viper.SetDefault("Terrain.Fractal.Persistence", 0.5)
+	viper.SetDefault("Terrain.Fractal.Frequency", 1.0)
+	viper.SetDefault("Terrain.Fractal.Offset", 1.0)
+	viper.SetDefault("Terrain.Fractal.Gain", 2.0)
+
+	viper.SetDefault("Terrain.Warp.Strength", 0.0)
+	viper.SetDefault("Terrain.Warp.Depth", 1)
+
+	viper.SetDefault("Terrain.Erosion.Passes", "")
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Iterations", 50000)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Rain", 1.0)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Evaporation", 0.01)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Capacity", 4.0)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Erosion", 0.3)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Deposition", 0.3)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Inertia", 0.05)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Gravity", 4.0)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.MinSlope", 0.01)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Lifetime", 30)
+	viper.SetDefault("Terrain.Erosion.Hydraulic.Radius", 3)
+	viper.SetDefault("Terrain.Erosion.Thermal.Iterations", 50)
+	viper.SetDefault("Terrain.Erosion.Thermal.Talus", 0.01)
+	viper.SetDefault("Terrain.Erosion.Thermal.Rate", 0.5)
+
+	viper.SetDefault("Animation.Frames", 24)
+	viper.SetDefault("Animation.Step", 0.05)
+	viper.SetDefault("Animation.Loop", false)
+	viper.SetDefault("Animation.Delay", 8)
 
 	viper.SetConfigName(".genesis")
 	viper.AddConfigPath("$HOME")
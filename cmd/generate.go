@@ -9,13 +9,14 @@ import (
 	terrain "github.com/therealfakemoot/genesis/map/terrain"
 	noise "github.com/therealfakemoot/genesis/noise"
 	"os"
+	"strings"
 )
 
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
 	Use:       "generate",
 	Short:     "A brief description of your command",
-	ValidArgs: []string{"all", "test", "terrain", "feature"},
+	ValidArgs: []string{"all", "test", "terrain", "wind", "animation", "feature"},
 	Args:      cobra.OnlyValidArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -25,17 +26,21 @@ var generateCmd = &cobra.Command{
 
 		layer := args[0]
 
+		world, err := worldSeed()
+		if err != nil {
+			l.Term.WithError(err).Error("Invalid world seed.")
+			return
+		}
+
 		switch layer {
 		case "all":
 			l.Term.Info("Full-map generation not implemented.")
 		case "test":
 			for i := .1; i < 10; i += .1 {
-				n := noise.NewWithSeed(18006665432)
-
-				mg := terrain.MapGen{
-					Stretch: -1.0 / 6,
-					Squish:  1 / 3,
-					Noise:   n,
+				mg, err := terrain.NewMapGen(world.Child("terrain/elevation").Seed())
+				if err != nil {
+					l.Term.WithError(err).Error("Failed to configure terrain generator.")
+					return
 				}
 
 				w := float64(viper.GetInt("mapX"))
@@ -49,23 +54,32 @@ var generateCmd = &cobra.Command{
 				fmt.Println(fmt.Sprintf("%s", terrainMap))
 			}
 		case "terrain":
-			n := noise.NewWithSeed(18006665432)
-			mg := terrain.MapGen{
-				Stretch: -1.0 / 6,
-				Squish:  1 / 3,
-				Noise:   n,
+			mg, err := terrain.NewMapGen(world.Child("terrain/elevation").Seed())
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to configure terrain generator.")
+				return
 			}
 
 			w := float64(viper.GetInt("mapX"))
 			h := float64(viper.GetInt("mapY"))
 
+			passes, err := terrain.NewPasses(world.Child("terrain/erosion").Seed())
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to configure terrain passes.")
+				return
+			}
+
 			terrainMap := mg.Generate(w, h, 2, 10.0)
 
+			for _, p := range passes {
+				p.Apply(terrainMap)
+			}
+
 			jsonBytes, _ := json.Marshal(terrainMap)
 
 			outFile := viper.GetString("mapDir")
 
-			err := os.Mkdir(outFile, 0755)
+			err = os.Mkdir(outFile, 0755)
 
 			if err != nil {
 				l.Term.WithError(err).Error("Failed to create map directory.")
@@ -90,6 +104,110 @@ var generateCmd = &cobra.Command{
 
 			terrain.RenderTopoHTML(terrainHTMLFile)
 
+		case "wind":
+			// The wind is the curl of its own noise field, configured like the terrain
+			// but seeded separately so it doesn't follow the elevation.
+			mg, err := terrain.NewMapGen(world.Child("wind").Seed())
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to configure wind generator.")
+				return
+			}
+
+			w := float64(viper.GetInt("mapX"))
+			h := float64(viper.GetInt("mapY"))
+
+			wind := mg.GenerateCurl(w, h, 2)
+
+			jsonBytes, _ := json.Marshal(wind)
+
+			outFile := viper.GetString("mapDir")
+
+			err = os.MkdirAll(outFile, 0755)
+
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to create map directory.")
+			}
+
+			windJSONFile, err := os.Create(outFile + "/wind.json")
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to open " + outFile + "/wind.json")
+				return
+			}
+			defer windJSONFile.Close()
+
+			windJSONFile.Write(jsonBytes)
+
+			windSVGFile, err := os.Create(outFile + "/wind.svg")
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to open " + outFile + "/wind.svg")
+				return
+			}
+			defer windSVGFile.Close()
+
+			if err = terrain.RenderArrowsSVG(windSVGFile, wind, 20, 1); err != nil {
+				l.Term.WithError(err).Error("Failed to write " + outFile + "/wind.svg")
+			}
+
+		case "animation":
+			mg, err := terrain.NewMapGen(world.Child("animation").Seed())
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to configure animation generator.")
+				return
+			}
+
+			w := float64(viper.GetInt("mapX"))
+			h := float64(viper.GetInt("mapY"))
+
+			frames, err := mg.Animate(w, h, viper.GetFloat64("sample"), terrain.Animation{
+				Frames: viper.GetInt("Animation.Frames"),
+				Step:   viper.GetFloat64("Animation.Step"),
+				Loop:   viper.GetBool("Animation.Loop"),
+			})
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to generate animation.")
+				return
+			}
+
+			outDir := viper.GetString("mapDir") + "/animation"
+
+			err = os.MkdirAll(outDir, 0755)
+
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to create animation directory.")
+				return
+			}
+
+			min, max := terrain.Range(frames...)
+
+			for i, frame := range frames {
+				name := fmt.Sprintf("%s/frame_%04d.png", outDir, i)
+
+				frameFile, err := os.Create(name)
+				if err != nil {
+					l.Term.WithError(err).Error("Failed to open " + name)
+					return
+				}
+
+				err = terrain.RenderMapPNG(frameFile, frame, min, max)
+				frameFile.Close()
+
+				if err != nil {
+					l.Term.WithError(err).Error("Failed to write " + name)
+					return
+				}
+			}
+
+			gifFile, err := os.Create(outDir + "/animation.gif")
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to open " + outDir + "/animation.gif")
+				return
+			}
+			defer gifFile.Close()
+
+			if err = terrain.RenderGIF(gifFile, frames, min, max, viper.GetInt("Animation.Delay")); err != nil {
+				l.Term.WithError(err).Error("Failed to write " + outDir + "/animation.gif")
+			}
+
 		case "feature":
 			l.Term.Info("Feature generation not implemented.")
 		}
@@ -105,6 +223,98 @@ func init() {
 	generateCmd.Flags().Int("sample", 2, "Vertical height of generated map")
 	generateCmd.Flags().Int("threshold", 10, "Vertical height of generated map")
 
+	generateCmd.Flags().Int("workers", 0, "Number of goroutines generating the map; 0 uses one per CPU")
+	generateCmd.Flags().Int("frames", 24, "Number of animation frames")
+	generateCmd.Flags().Float64("timeStep", 0.05, "Distance moved through the noise between animation frames")
+	generateCmd.Flags().Bool("loop", false, "Make the animation loop seamlessly ( needs opensimplex noise, which has a fourth dimension )")
+	generateCmd.Flags().Int("frameDelay", 8, "Time each animation frame is shown, in hundredths of a second")
+	generateCmd.Flags().String("algorithm", "noise", "Terrain algorithm: noise, diamond-square, fault-lines, particle-deposition or spectral")
+	generateCmd.Flags().Float64("roughness", 0.7, "Diamond-square roughness; lower is rougher")
+	generateCmd.Flags().Int("faults", 200, "Number of fault lines")
+	generateCmd.Flags().Int("particles", 200000, "Number of particles dropped by particle deposition")
+	generateCmd.Flags().Int("drops", 20, "Number of wandering vents particles are dropped from")
+	generateCmd.Flags().Float64("exponent", 2.0, "Spectral synthesis power-law exponent; higher is smoother")
+	generateCmd.Flags().String("erosion", "", "Comma-separated passes run over the finished terrain, in order: hydraulic or thermal")
+	generateCmd.Flags().Int("erosionIterations", 50000, "Number of raindrops simulated by hydraulic erosion")
+	generateCmd.Flags().Float64("rain", 1.0, "Water each raindrop starts with")
+	generateCmd.Flags().Float64("evaporation", 0.01, "Fraction of its water a raindrop loses at each step")
+	generateCmd.Flags().Float64("sedimentCapacity", 4.0, "Sediment a raindrop can carry for its speed, water and slope")
+	generateCmd.Flags().Float64("erosionRate", 0.3, "Fraction of its spare capacity a raindrop picks up at each step")
+	generateCmd.Flags().Float64("depositionRate", 0.3, "Fraction of its excess sediment a raindrop drops at each step")
+	generateCmd.Flags().Float64("inertia", 0.05, "How much raindrops keep their heading instead of turning downhill, from 0 to 1")
+	generateCmd.Flags().Int("thermalIterations", 50, "Number of thermal erosion sweeps over the map")
+	generateCmd.Flags().Float64("talus", 0.01, "Steepest slope thermal erosion leaves standing, as the height difference between neighbouring cells")
+	generateCmd.Flags().Float64("thermalRate", 0.5, "Fraction of the slope above the talus angle moved in each thermal sweep, from 0 to 1")
+	generateCmd.Flags().String("noise", "opensimplex", "Noise algorithm: "+strings.Join(noise.Algorithms, ", "))
+	generateCmd.Flags().String("projection", "flat", "Map projection: flat or sphere ( equirectangular planet )")
+	generateCmd.Flags().Float64("stretch", -1.0/6, "OpenSimplex 3D stretch constant, within (-1/3, -1/6]; the squish constant is derived from it")
+	generateCmd.Flags().Float64("norm", 103, "OpenSimplex 3D normalization divisor")
+	generateCmd.Flags().String("normalization", "raw", "Output range: raw, clamp ( [-1, 1] ) or unit ( [0, 1] )")
+	generateCmd.Flags().String("lattice", "table", "OpenSimplex lattice: table ( repeats every 256 units, as before ) or hash ( no visible repetition )")
+	generateCmd.Flags().String("worleyMetric", "euclidean", "Worley distance metric: euclidean, manhattan or chebyshev")
+	generateCmd.Flags().String("worleyReturn", "f1", "Worley distance to report: f1, f2 or f2-f1")
+	generateCmd.Flags().Float64("gaborOrientation", 0, "Gabor stripe direction, in degrees")
+	generateCmd.Flags().Float64("gaborFrequency", 1.0, "Gabor stripes per unit")
+	generateCmd.Flags().Float64("gaborBandwidth", 0.5, "Gabor envelope width relative to the wavelength; lower gives longer stripes")
+	generateCmd.Flags().Int("gaborImpulses", 8, "Gabor kernels per convolution cell")
+	generateCmd.Flags().String("gaborSteer", "", "Noise algorithm that turns the Gabor stripes from place to place; empty keeps them straight")
+	generateCmd.Flags().Float64("gaborSteerFrequency", 0.1, "Frequency of the Gabor steering noise")
+	generateCmd.Flags().String("mode", "fbm", "Fractal mode: fbm, billow, ridged or hybrid")
+	generateCmd.Flags().Int("octaves", 6, "Number of noise octaves summed into the terrain")
+	generateCmd.Flags().Float64("lacunarity", 2.0, "Frequency multiplier between successive octaves")
+	generateCmd.Flags().Float64("persistence", 0.5, "Amplitude multiplier between successive octaves")
+	generateCmd.Flags().Float64("frequency", 1.0, "Frequency of the first octave")
+	generateCmd.Flags().Float64("offset", 1.0, "Ridged/hybrid offset added to each octave")
+	generateCmd.Flags().Float64("gain", 2.0, "Ridged weighting gain between successive octaves")
+	generateCmd.Flags().Float64("warp", 0.0, "Domain warp strength; 0 disables warping")
+	generateCmd.Flags().Int("warpDepth", 1, "Number of recursive domain warp levels")
+
+	viper.BindPFlag("Terrain.Workers", generateCmd.Flags().Lookup("workers"))
+	viper.BindPFlag("Animation.Frames", generateCmd.Flags().Lookup("frames"))
+	viper.BindPFlag("Animation.Step", generateCmd.Flags().Lookup("timeStep"))
+	viper.BindPFlag("Animation.Loop", generateCmd.Flags().Lookup("loop"))
+	viper.BindPFlag("Animation.Delay", generateCmd.Flags().Lookup("frameDelay"))
+	viper.BindPFlag("Terrain.Algorithm", generateCmd.Flags().Lookup("algorithm"))
+	viper.BindPFlag("Terrain.Roughness", generateCmd.Flags().Lookup("roughness"))
+	viper.BindPFlag("Terrain.Faults", generateCmd.Flags().Lookup("faults"))
+	viper.BindPFlag("Terrain.Particles", generateCmd.Flags().Lookup("particles"))
+	viper.BindPFlag("Terrain.Drops", generateCmd.Flags().Lookup("drops"))
+	viper.BindPFlag("Terrain.Exponent", generateCmd.Flags().Lookup("exponent"))
+	viper.BindPFlag("Terrain.Erosion.Passes", generateCmd.Flags().Lookup("erosion"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Iterations", generateCmd.Flags().Lookup("erosionIterations"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Rain", generateCmd.Flags().Lookup("rain"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Evaporation", generateCmd.Flags().Lookup("evaporation"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Capacity", generateCmd.Flags().Lookup("sedimentCapacity"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Erosion", generateCmd.Flags().Lookup("erosionRate"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Deposition", generateCmd.Flags().Lookup("depositionRate"))
+	viper.BindPFlag("Terrain.Erosion.Hydraulic.Inertia", generateCmd.Flags().Lookup("inertia"))
+	viper.BindPFlag("Terrain.Erosion.Thermal.Iterations", generateCmd.Flags().Lookup("thermalIterations"))
+	viper.BindPFlag("Terrain.Erosion.Thermal.Talus", generateCmd.Flags().Lookup("talus"))
+	viper.BindPFlag("Terrain.Erosion.Thermal.Rate", generateCmd.Flags().Lookup("thermalRate"))
+	viper.BindPFlag("Terrain.Noise", generateCmd.Flags().Lookup("noise"))
+	viper.BindPFlag("Terrain.Projection", generateCmd.Flags().Lookup("projection"))
+	viper.BindPFlag("Terrain.Stretch", generateCmd.Flags().Lookup("stretch"))
+	viper.BindPFlag("Terrain.Norm", generateCmd.Flags().Lookup("norm"))
+	viper.BindPFlag("Terrain.Normalization", generateCmd.Flags().Lookup("normalization"))
+	viper.BindPFlag("Terrain.Lattice", generateCmd.Flags().Lookup("lattice"))
+	viper.BindPFlag("Terrain.Worley.Metric", generateCmd.Flags().Lookup("worleyMetric"))
+	viper.BindPFlag("Terrain.Worley.Return", generateCmd.Flags().Lookup("worleyReturn"))
+	viper.BindPFlag("Terrain.Gabor.Orientation", generateCmd.Flags().Lookup("gaborOrientation"))
+	viper.BindPFlag("Terrain.Gabor.Frequency", generateCmd.Flags().Lookup("gaborFrequency"))
+	viper.BindPFlag("Terrain.Gabor.Bandwidth", generateCmd.Flags().Lookup("gaborBandwidth"))
+	viper.BindPFlag("Terrain.Gabor.Impulses", generateCmd.Flags().Lookup("gaborImpulses"))
+	viper.BindPFlag("Terrain.Gabor.Steer", generateCmd.Flags().Lookup("gaborSteer"))
+	viper.BindPFlag("Terrain.Gabor.SteerFrequency", generateCmd.Flags().Lookup("gaborSteerFrequency"))
+	viper.BindPFlag("Terrain.Fractal.Mode", generateCmd.Flags().Lookup("mode"))
+	viper.BindPFlag("Terrain.Fractal.Octaves", generateCmd.Flags().Lookup("octaves"))
+	viper.BindPFlag("Terrain.Fractal.Lacunarity", generateCmd.Flags().Lookup("lacunarity"))
+	viper.BindPFlag("Terrain.Fractal.Persistence", generateCmd.Flags().Lookup("persistence"))
+	viper.BindPFlag("Terrain.Fractal.Frequency", generateCmd.Flags().Lookup("frequency"))
+	viper.BindPFlag("Terrain.Fractal.Offset", generateCmd.Flags().Lookup("offset"))
+	viper.BindPFlag("Terrain.Fractal.Gain", generateCmd.Flags().Lookup("gain"))
+	viper.BindPFlag("Terrain.Warp.Strength", generateCmd.Flags().Lookup("warp"))
+	viper.BindPFlag("Terrain.Warp.Depth", generateCmd.Flags().Lookup("warpDepth"))
+
 	generateCmd.MarkFlagRequired("mapDir")
 
 	// Here you will define your flags and configuration settings.
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	l "github.com/therealfakemoot/genesis/log"
+	terrain "github.com/therealfakemoot/genesis/map/terrain"
+	noise "github.com/therealfakemoot/genesis/noise"
+	"os"
+	"strings"
+)
+
+// noiseCmd groups the tools for working with noise sources directly.
+var noiseCmd = &cobra.Command{
+	Use:   "noise",
+	Short: "Preview and analyse noise sources",
+}
+
+// inspectCmd renders a noise source to a PNG and prints statistics about it.
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Preview a noise source and print statistics about it",
+	Long: `Sample a noise source over a grid, write it to a grayscale PNG and
+print its range, mean, standard deviation, a histogram of its values and
+an estimate of its dominant feature size.
+
+The source is seeded the same way generate seeds the terrain stage, but
+it is built only from the flags here: the Terrain settings generate uses,
+such as the lattice, normalization, warp or a module graph, are ignored,
+and --scale stands in for generate's sample scale. Use it to study a
+single source and tune its parameters, not to preview a finished map.
+
+noise inspect --algorithm perlin --octaves 4 --scale 0.02 -o perlin.png
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+
+		algorithm, _ := flags.GetString("algorithm")
+		modeName, _ := flags.GetString("mode")
+		octaves, _ := flags.GetInt("octaves")
+		lacunarity, _ := flags.GetFloat64("lacunarity")
+		persistence, _ := flags.GetFloat64("persistence")
+		scale, _ := flags.GetFloat64("scale")
+		width, _ := flags.GetInt("width")
+		height, _ := flags.GetInt("height")
+		bins, _ := flags.GetInt("bins")
+		out, _ := flags.GetString("out")
+
+		switch {
+		case width < 1 || height < 1:
+			l.Term.Errorf("Invalid grid size %dx%d; --width and --height must be at least 1.", width, height)
+			return
+		case bins < 1:
+			l.Term.Errorf("Invalid --bins %d; at least one histogram bin is needed.", bins)
+			return
+		case octaves < 1:
+			l.Term.Errorf("Invalid --octaves %d; at least one octave is needed.", octaves)
+			return
+		case !(scale > 0):
+			l.Term.Errorf("Invalid --scale %v; the distance between samples must be positive.", scale)
+			return
+		}
+
+		world, err := worldSeed()
+		if err != nil {
+			l.Term.WithError(err).Error("Invalid world seed.")
+			return
+		}
+
+		src, err := noise.NewSource(algorithm, world.Child("terrain/elevation").Seed())
+		if err != nil {
+			l.Term.WithError(err).Error("Failed to create noise source.")
+			return
+		}
+
+		mode, err := noise.ParseFractalMode(modeName)
+		if err != nil {
+			l.Term.WithError(err).Error("Failed to create noise source.")
+			return
+		}
+
+		if octaves > 1 || mode != noise.FBM {
+			f := noise.NewFractal(src)
+			f.Mode = mode
+			f.Octaves = octaves
+			f.Lacunarity = lacunarity
+			f.Persistence = persistence
+			src = f
+		}
+
+		r := noise.Region{Width: width, Height: height, Step: scale}
+		values := make([]float64, r.Len())
+		if err = noise.FillRegion(src, values, r, 0); err != nil {
+			l.Term.WithError(err).Error("Failed to sample noise source.")
+			return
+		}
+
+		stats := noise.Analyze(values, width, height, bins)
+
+		if out != "" {
+			f, err := os.Create(out)
+			if err != nil {
+				l.Term.WithError(err).Error("Failed to create " + out)
+				return
+			}
+			defer f.Close()
+
+			if err = terrain.RenderGrayscalePNG(f, values, width, height, stats.Min, stats.Max); err != nil {
+				l.Term.WithError(err).Error("Failed to write " + out)
+				return
+			}
+		}
+
+		l.Term.WithFields(logrus.Fields{
+			"algorithm": algorithm,
+			"mode":      mode,
+			"octaves":   octaves,
+			"scale":     scale,
+		}).Debug("Inspect")
+
+		printStats(stats, scale)
+	},
+}
+
+func printStats(s noise.Stats, scale float64) {
+	fmt.Printf("min:    %9.4f\n", s.Min)
+	fmt.Printf("max:    %9.4f\n", s.Max)
+	fmt.Printf("mean:   %9.4f\n", s.Mean)
+	fmt.Printf("stddev: %9.4f\n", s.StdDev)
+
+	if s.FeatureSize > 0 {
+		fmt.Printf("feature size: %.1f samples ( %.4f units )\n", s.FeatureSize, s.FeatureSize*scale)
+	} else {
+		fmt.Println("feature size: larger than the sampled region; lower --scale or enlarge the region")
+	}
+
+	peak := 0
+	for _, c := range s.Histogram {
+		if c > peak {
+			peak = c
+		}
+	}
+	if peak == 0 {
+		return
+	}
+
+	const barWidth = 50
+	binWidth := (s.Max - s.Min) / float64(len(s.Histogram))
+
+	fmt.Println("histogram:")
+	for i, c := range s.Histogram {
+		lo := s.Min + float64(i)*binWidth
+		fmt.Printf("%9.4f %8d %s\n", lo, c, strings.Repeat("#", c*barWidth/peak))
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(noiseCmd)
+	noiseCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().String("algorithm", "opensimplex", "Noise algorithm: "+strings.Join(noise.Algorithms, ", "))
+	inspectCmd.Flags().String("mode", "fbm", "Fractal mode: fbm, billow, ridged or hybrid")
+	inspectCmd.Flags().Int("octaves", 1, "Number of noise octaves summed into the preview")
+	inspectCmd.Flags().Float64("lacunarity", 2.0, "Frequency multiplier between successive octaves")
+	inspectCmd.Flags().Float64("persistence", 0.5, "Amplitude multiplier between successive octaves")
+	inspectCmd.Flags().Float64("scale", 0.05, "Distance between samples, as passed to generate's sample scale")
+	inspectCmd.Flags().Int("width", 256, "Width of the sampled grid")
+	inspectCmd.Flags().Int("height", 256, "Height of the sampled grid")
+	inspectCmd.Flags().Int("bins", 10, "Number of histogram bins")
+	inspectCmd.Flags().StringP("out", "o", "", "PNG file to write the preview to")
+}
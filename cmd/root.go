@@ -60,6 +60,9 @@ func init() {
 	// will be global for your application.
 	RootCmd.Flags().String("config", "", "config file (default is $HOME/.genesis.yaml)")
 	RootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging. [POSSIBLE PERFORMANCE IMPLICATIONS]")
+	RootCmd.PersistentFlags().String("seed", "18006665432", "World seed, or \"random\" to pick one")
+
+	viper.BindPFlag("seed", RootCmd.PersistentFlags().Lookup("seed"))
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
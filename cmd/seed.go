@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	l "github.com/therealfakemoot/genesis/log"
+	seed "github.com/therealfakemoot/genesis/seed"
+	"strings"
+)
+
+// worldSeed returns the root of the seed tree for this run. A "random" seed is
+// resolved once and written back into the configuration, so it shows up in the
+// settings dump and can be passed to --seed to reproduce the world.
+func worldSeed() (seed.Tree, error) {
+	configured := strings.TrimSpace(viper.GetString("seed"))
+
+	s, err := seed.Parse(configured)
+	if err != nil {
+		return seed.Tree{}, err
+	}
+
+	if strings.EqualFold(configured, seed.Random) {
+		viper.Set("seed", s)
+
+		l.Term.WithFields(logrus.Fields{
+			"seed": s,
+		}).Info("Chose random world seed")
+	}
+
+	return seed.New(s), nil
+}
@@ -1,10 +1,8 @@
 package genesis
 
 import (
-	"math/rand"
 	"reflect"
 	"testing"
-	"time"
 )
 
 type TreeAxis struct {
@@ -18,24 +16,16 @@ func featureTree(depth TreeAxis, width TreeAxis, locMapFunc LocMapFunc) *Feature
 	return randomLocFeature()
 }
 
+// randomLocFeature returns a Feature at a scattered but reproducible location.
 func randomLocFeature() *Feature {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	x := r.Int()
-	y := r.Int()
-	z := r.Int()
-
-	return dummyFeature(x, y, z)
-
-}
-
-func dummyFeature(x int, y int, z int) *Feature {
-	m := map[string]interface{}{
-		"x": x,
-		"y": y,
-		"z": z,
-	}
-
-	f, _ := NewFeature(m)
+	p := PoissonSampler{Width: 1000, Height: 1000, MinRadius: 10, Seed: 18006665432}
+	s := p.Sample()[0]
+
+	f, _ := NewFeature(map[string]interface{}{
+		"x": s.X,
+		"y": s.Y,
+		"z": 0.0,
+	})
 	return f
 }
 
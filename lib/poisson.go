@@ -0,0 +1,158 @@
+package genesis
+
+import (
+	"math"
+
+	seed "github.com/therealfakemoot/genesis/seed"
+)
+
+// Sample is a point placed by a PoissonSampler.
+type Sample struct {
+	X      float64
+	Y      float64
+	Radius float64
+}
+
+// Feature returns a Feature named name located at s. Its LocMap holds the "x" and "y"
+// coordinates of the sample.
+func (s Sample) Feature(name string) Feature {
+	return Feature{
+		Name: name,
+		LocMap: Point{
+			"x": s.X,
+			"y": s.Y,
+		},
+	}
+}
+
+// PoissonSampler scatters points over a Width x Height rectangle so that no two lie
+// closer together than the local spacing radius, using Bridson's algorithm. The
+// result is blue noise: evenly spread like a grid but without its regularity, which
+// suits trees, villages and other features that crowd each other out.
+//
+// The spacing varies between MinRadius where Density is 1 and MaxRadius where it is
+// 0. Two points must be at least the larger of their two radii apart. A nil Density,
+// or a MaxRadius no larger than MinRadius, spaces every point MinRadius apart.
+//
+// Sampling is deterministic: the same sampler and Seed always produce the same points.
+type PoissonSampler struct {
+	Width  float64
+	Height float64
+
+	MinRadius float64
+	MaxRadius float64
+	Density   func(x, y float64) float64
+
+	// Attempts is the number of candidates tried around each point before giving up
+	// on it. Bridson recommends 30, which is used when Attempts is 0.
+	Attempts int
+
+	Seed int64
+}
+
+// Sample returns the scattered points in the order they were placed.
+func (p PoissonSampler) Sample() []Sample {
+	if p.Width <= 0 || p.Height <= 0 || p.MinRadius <= 0 {
+		return nil
+	}
+
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 30
+	}
+	maxRadius := math.Max(p.MinRadius, p.MaxRadius)
+
+	// No two points can share a cell this size, because its diagonal is MinRadius.
+	cell := p.MinRadius / math.Sqrt2
+	cols, rows := int(math.Ceil(p.Width/cell)), int(math.Ceil(p.Height/cell))
+	grid := make([]int, cols*rows)
+	for i := range grid {
+		grid[i] = -1
+	}
+	reach := int(math.Ceil(maxRadius / cell))
+
+	rng := seed.NewStream(p.Seed)
+	var samples []Sample
+	var active []int
+
+	place := func(x, y float64) {
+		s := Sample{X: x, Y: y, Radius: p.radius(x, y, maxRadius)}
+		grid[int(y/cell)*cols+int(x/cell)] = len(samples)
+		active = append(active, len(samples))
+		samples = append(samples, s)
+	}
+
+	fits := func(x, y, r float64) bool {
+		ci, cj := int(x/cell), int(y/cell)
+		for j := cj - reach; j <= cj+reach; j++ {
+			if j < 0 || j >= rows {
+				continue
+			}
+			for i := ci - reach; i <= ci+reach; i++ {
+				if i < 0 || i >= cols || grid[j*cols+i] < 0 {
+					continue
+				}
+
+				n := samples[grid[j*cols+i]]
+				if d := math.Max(r, n.Radius); (n.X-x)*(n.X-x)+(n.Y-y)*(n.Y-y) < d*d {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	place(rng.Float64()*p.Width, rng.Float64()*p.Height)
+
+	for len(active) > 0 {
+		a := rng.Intn(len(active))
+		s := samples[active[a]]
+
+		found := false
+		for k := 0; k < attempts; k++ {
+			// Candidates are drawn uniformly by area from the annulus between one
+			// and two radii around s.
+			theta := 2 * math.Pi * rng.Float64()
+			d := s.Radius * math.Sqrt(1+3*rng.Float64())
+			x, y := s.X+d*math.Cos(theta), s.Y+d*math.Sin(theta)
+
+			if x < 0 || x >= p.Width || y < 0 || y >= p.Height {
+				continue
+			}
+			if fits(x, y, p.radius(x, y, maxRadius)) {
+				place(x, y)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			active[a] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	return samples
+}
+
+// Features scatters points with p and returns a Feature named name at each of them.
+func (p PoissonSampler) Features(name string) []Feature {
+	samples := p.Sample()
+
+	features := make([]Feature, len(samples))
+	for i, s := range samples {
+		features[i] = s.Feature(name)
+	}
+
+	return features
+}
+
+// radius returns the spacing at x,y.
+func (p PoissonSampler) radius(x, y, maxRadius float64) float64 {
+	if p.Density == nil || maxRadius == p.MinRadius {
+		return p.MinRadius
+	}
+
+	d := math.Max(0, math.Min(1, p.Density(x, y)))
+	return maxRadius - d*(maxRadius-p.MinRadius)
+}
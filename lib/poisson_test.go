@@ -0,0 +1,89 @@
+package genesis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPoissonSpacing(t *testing.T) {
+	p := PoissonSampler{
+		Width:     100,
+		Height:    60,
+		MinRadius: 2,
+		MaxRadius: 6,
+		Density:   func(x, y float64) float64 { return x / 100 },
+		Seed:      18006665432,
+	}
+
+	samples := p.Sample()
+	if len(samples) == 0 {
+		t.Fatalf("Expected samples, got none")
+	}
+
+	for i, a := range samples {
+		if a.X < 0 || a.X >= p.Width || a.Y < 0 || a.Y >= p.Height {
+			t.Fatalf("Expected samples inside the rectangle, got (%v, %v)", a.X, a.Y)
+		}
+
+		for _, b := range samples[i+1:] {
+			if d := math.Hypot(a.X-b.X, a.Y-b.Y); d < math.Max(a.Radius, b.Radius) {
+				t.Fatalf("Expected (%v, %v) and (%v, %v) at least %v apart, got %v", a.X, a.Y, b.X, b.Y, math.Max(a.Radius, b.Radius), d)
+			}
+		}
+	}
+
+	// The dense right half should hold more points than the sparse left half.
+	var left, right int
+	for _, s := range samples {
+		if s.X < p.Width/2 {
+			left++
+		} else {
+			right++
+		}
+	}
+	if right <= left {
+		t.Errorf("Expected more samples where density is higher, got %d left and %d right", left, right)
+	}
+}
+
+func TestPoissonCoverage(t *testing.T) {
+	p := PoissonSampler{Width: 50, Height: 50, MinRadius: 3, Seed: 1}
+	samples := p.Sample()
+
+	// Bridson's algorithm is maximal: every point of the rectangle lies within two
+	// radii of some sample.
+	for y := 0.0; y < p.Height; y++ {
+		for x := 0.0; x < p.Width; x++ {
+			covered := false
+			for _, s := range samples {
+				if math.Hypot(s.X-x, s.Y-y) < 2*p.MinRadius {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				t.Fatalf("Expected (%v, %v) to be within %v of a sample", x, y, 2*p.MinRadius)
+			}
+		}
+	}
+}
+
+func TestPoissonDeterministic(t *testing.T) {
+	p := PoissonSampler{Width: 40, Height: 40, MinRadius: 2, Seed: 99}
+
+	if !reflect.DeepEqual(p.Sample(), p.Sample()) {
+		t.Errorf("Expected the same seed to give the same samples")
+	}
+
+	q := p
+	q.Seed = 100
+	if reflect.DeepEqual(p.Sample(), q.Sample()) {
+		t.Errorf("Expected different seeds to give different samples")
+	}
+
+	features := p.Features("tree")
+	if len(features) == 0 || features[0].Name != "tree" || features[0].LocMap["x"] != p.Sample()[0].X {
+		t.Errorf("Expected features located at the samples, got %+v", features[0])
+	}
+}
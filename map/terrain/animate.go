@@ -0,0 +1,62 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+
+	noise "github.com/therealfakemoot/genesis/noise"
+)
+
+// Animation describes a sequence of frames made by moving through the noise over
+// time.
+//
+// Frame i of a plain animation samples the plane z = i*Step, so consecutive frames
+// evolve smoothly but the last never joins up with the first. A looping animation
+// instead walks a circle through the third and fourth dimensions, one Step per frame,
+// so it returns exactly to its first frame. Looping needs a noise source with a
+// fourth dimension all the way down, as reported by noise.Is4D: OpenSimplex, alone
+// or under Fractal, Warp and the graph modules. Perlin, value, Worley and Gabor
+// noise have no fourth dimension, and neither does a graph that uses them.
+type Animation struct {
+	Frames int
+	Step   float64
+	Loop   bool
+}
+
+// Animate generates a.Frames maps of x by y cells, sampled like Generate on a flat
+// projection.
+func (mg *MapGen) Animate(x, y, sampleScale float64, a Animation) ([]Map, error) {
+	if a.Frames < 1 {
+		return nil, fmt.Errorf("animation needs at least one frame, got %d", a.Frames)
+	}
+
+	var src4 noise.Source4
+	if a.Loop {
+		var ok bool
+		if src4, ok = mg.Noise.(noise.Source4); !ok || !noise.Is4D(mg.Noise) {
+			return nil, fmt.Errorf("looping animation needs 4D noise, but %T has no fourth dimension", mg.Noise)
+		}
+	}
+
+	// The circle's circumference is Frames steps long.
+	radius := a.Step * float64(a.Frames) / (2 * math.Pi)
+
+	frames := make([]Map, a.Frames)
+	for i := range frames {
+		if !a.Loop {
+			z := float64(i) * a.Step
+			frames[i] = mg.generate(x, y, func(xGen, yGen float64) float64 {
+				return mg.Noise.Eval3(xGen*sampleScale, yGen*sampleScale, z)
+			})
+			continue
+		}
+
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(a.Frames))
+		z, w := radius*cos, radius*sin
+		frames[i] = mg.generate(x, y, func(xGen, yGen float64) float64 {
+			return src4.Eval4(xGen*sampleScale, yGen*sampleScale, z, w)
+		})
+	}
+
+	return frames, nil
+}
@@ -0,0 +1,47 @@
+package genesis
+
+import (
+	"reflect"
+	"testing"
+
+	noise "github.com/therealfakemoot/genesis/noise"
+)
+
+func TestAnimateLoop(t *testing.T) {
+	setDomain()
+
+	loop := Animation{Frames: 8, Step: 0.5, Loop: true}
+
+	var loopTests = []struct {
+		name string
+		in   noise.Source
+		ok   bool
+	}{
+		{"opensimplex", noise.NewFractal(noise.NewWithSeed(1)), true},
+		{"warped opensimplex", noise.NewWarp(noise.NewFractal(noise.NewWithSeed(1)), 0.5), true},
+		{"perlin", noise.NewFractal(noise.NewPerlinWithSeed(1)), false},
+		{"warped by perlin", &noise.Warp{Source: noise.NewWithSeed(1), Field: noise.NewPerlinWithSeed(1), Strength: 0.5, Depth: 1}, false},
+	}
+
+	for _, tt := range loopTests {
+		mg := &MapGen{Noise: tt.in}
+
+		frames, err := mg.Animate(16, 16, 0.1, loop)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s: Expected an error for noise without a fourth dimension, got nil", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected the animation to loop, got %v", tt.name, err)
+			continue
+		}
+
+		// Without a fourth dimension the circle collapses onto a line through z, and
+		// the animation plays back on itself: frame 1 matches frame 7.
+		if reflect.DeepEqual(frames[1].Points, frames[7].Points) {
+			t.Errorf("%s: Expected frames on opposite sides of the loop to differ", tt.name)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package genesis
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ChunkCoord identifies a chunk of an unbounded flat world. Chunk X,Y covers the
+// world cells [X*size, (X+1)*size) by [Y*size, (Y+1)*size) for a chunk size of size.
+// Coordinates may be negative.
+type ChunkCoord struct {
+	X int
+	Y int
+}
+
+func (c ChunkCoord) String() string {
+	return fmt.Sprintf("%d,%d", c.X, c.Y)
+}
+
+// GenerateChunk generates the size x size chunk at c. Every cell is sampled at its
+// world position, exactly as Generate samples the same cell, so neighbouring chunks
+// join without seams and a chunk made today matches one made later from the same
+// seed.
+//
+// Chunks need a flat, noise-based MapGen: the Heightmap generators build a single
+// finite grid and the Sphere projection has no room for neighbours.
+func (mg *MapGen) GenerateChunk(c ChunkCoord, size int, sampleScale float64) (Map, error) {
+	if mg.Heightmap != nil {
+		return Map{}, fmt.Errorf("chunks need a noise-based terrain, got %T", mg.Heightmap)
+	}
+	if mg.Projection == Sphere {
+		return Map{}, fmt.Errorf("chunks need the %s projection, got %s", Flat, mg.Projection)
+	}
+	if size < 1 {
+		return Map{}, fmt.Errorf("invalid chunk size %d", size)
+	}
+
+	x0, y0 := float64(c.X*size), float64(c.Y*size)
+
+	return mg.generate(float64(size), float64(size), func(xGen, yGen float64) float64 {
+		return mg.Noise.Eval3((x0+xGen)*sampleScale, (y0+yGen)*sampleScale, 0)
+	}), nil
+}
+
+// ChunkHeader records what a stored chunk was generated with: the world seed, the
+// sample scale and the chunk size. A chunk is only reused by a ChunkStore whose
+// header matches, so a backing shared between worlds or settings never mixes them.
+type ChunkHeader struct {
+	Seed        int64   `json:"seed"`
+	SampleScale float64 `json:"sampleScale"`
+	Size        int     `json:"size"`
+}
+
+// ChunkBacking persists chunks outside a ChunkStore's cache, each with the header it
+// was saved under. Load reports false when it has no copy of the chunk.
+type ChunkBacking interface {
+	Load(c ChunkCoord) (ChunkHeader, Map, bool, error)
+	Save(c ChunkCoord, h ChunkHeader, m Map) error
+}
+
+// ChunkStore hands out the chunks of a world on demand, keeping the most recently
+// used Capacity chunks in memory. A chunk missing from the cache is loaded from
+// Backing if it has a copy saved with the same Seed, SampleScale and Size, and
+// generated otherwise; generated chunks are saved to Backing, replacing any stale
+// copy, so they need not be generated again.
+//
+// Seed only labels the chunks in Backing; the terrain itself comes from Gen, which
+// must have been built from the same seed.
+//
+// ChunkStore is safe for concurrent use, and a ChunkStore literal works as well as
+// one from NewChunkStore. Two goroutines asking for the same missing chunk at once
+// may both generate it, but they get identical maps.
+type ChunkStore struct {
+	Gen         *MapGen
+	Seed        int64
+	Size        int
+	SampleScale float64
+	Capacity    int
+	// Backing is optional.
+	Backing ChunkBacking
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[ChunkCoord]*list.Element
+}
+
+type chunkEntry struct {
+	coord ChunkCoord
+	m     Map
+}
+
+// NewChunkStore returns a ChunkStore of size x size chunks generated by mg, which was
+// built from seed, that caches up to capacity chunks.
+func NewChunkStore(mg *MapGen, seed int64, size int, sampleScale float64, capacity int) *ChunkStore {
+	return &ChunkStore{
+		Gen:         mg,
+		Seed:        seed,
+		Size:        size,
+		SampleScale: sampleScale,
+		Capacity:    capacity,
+	}
+}
+
+// header returns the ChunkHeader chunks from s are saved under.
+func (s *ChunkStore) header() ChunkHeader {
+	return ChunkHeader{Seed: s.Seed, SampleScale: s.SampleScale, Size: s.Size}
+}
+
+// init sets up the cache on first use. s.mu must be held.
+func (s *ChunkStore) init() {
+	if s.items == nil {
+		s.lru = list.New()
+		s.items = make(map[ChunkCoord]*list.Element)
+	}
+}
+
+// Get returns the chunk at c. The Map is shared with the cache; don't modify it.
+func (s *ChunkStore) Get(c ChunkCoord) (Map, error) {
+	s.mu.Lock()
+	s.init()
+	if e, ok := s.items[c]; ok {
+		s.lru.MoveToFront(e)
+		m := e.Value.(*chunkEntry).m
+		s.mu.Unlock()
+		return m, nil
+	}
+	s.mu.Unlock()
+
+	m, err := s.fetch(c)
+	if err != nil {
+		return Map{}, fmt.Errorf("chunk %s: %v", c, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.items[c]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*chunkEntry).m, nil
+	}
+
+	s.items[c] = s.lru.PushFront(&chunkEntry{coord: c, m: m})
+	for s.Capacity > 0 && s.lru.Len() > s.Capacity {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.items, oldest.Value.(*chunkEntry).coord)
+	}
+
+	return m, nil
+}
+
+// Len returns the number of chunks in the cache.
+func (s *ChunkStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *ChunkStore) fetch(c ChunkCoord) (Map, error) {
+	h := s.header()
+
+	if s.Backing != nil {
+		stored, m, ok, err := s.Backing.Load(c)
+		if err != nil {
+			return Map{}, err
+		}
+		// A chunk from another world or other settings is treated as missing.
+		if ok && stored == h {
+			if m.Grid.X != s.Size || m.Grid.Y != s.Size {
+				return Map{}, fmt.Errorf("stored chunk is %dx%d, but its header says %dx%d", m.Grid.X, m.Grid.Y, s.Size, s.Size)
+			}
+			return m, nil
+		}
+	}
+
+	m, err := s.Gen.GenerateChunk(c, s.Size, s.SampleScale)
+	if err != nil {
+		return Map{}, err
+	}
+
+	if s.Backing != nil {
+		if err = s.Backing.Save(c, h, m); err != nil {
+			return Map{}, err
+		}
+	}
+
+	return m, nil
+}
+
+// ChunkDir is a ChunkBacking that keeps each chunk as a JSON file, named after its
+// coordinates, in a directory.
+type ChunkDir string
+
+// chunkFile is the JSON layout of a ChunkDir file.
+type chunkFile struct {
+	Header ChunkHeader `json:"header"`
+	Map    Map         `json:"map"`
+}
+
+func (d ChunkDir) path(c ChunkCoord) string {
+	return filepath.Join(string(d), fmt.Sprintf("chunk_%d_%d.json", c.X, c.Y))
+}
+
+// Load reads the chunk at c and its header, if it has been saved.
+func (d ChunkDir) Load(c ChunkCoord) (ChunkHeader, Map, bool, error) {
+	data, err := ioutil.ReadFile(d.path(c))
+	if os.IsNotExist(err) {
+		return ChunkHeader{}, Map{}, false, nil
+	}
+	if err != nil {
+		return ChunkHeader{}, Map{}, false, err
+	}
+
+	var f chunkFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return ChunkHeader{}, Map{}, false, err
+	}
+
+	return f.Header, f.Map, true, nil
+}
+
+// Save writes the chunk at c with its header, creating the directory if needed.
+func (d ChunkDir) Save(c ChunkCoord, h ChunkHeader, m Map) error {
+	data, err := json.Marshal(chunkFile{Header: h, Map: m})
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(string(d), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(d.path(c), data, 0644)
+}
@@ -0,0 +1,136 @@
+package genesis
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	noise "github.com/therealfakemoot/genesis/noise"
+)
+
+func TestChunkSeams(t *testing.T) {
+	setDomain()
+
+	const size, scale = 16, 0.07
+	mg := &MapGen{Noise: noise.NewFractal(noise.NewWithSeed(18006665432))}
+	whole := mg.Generate(2*size, 2*size, scale, 10)
+
+	for cy := 0; cy < 2; cy++ {
+		for cx := 0; cx < 2; cx++ {
+			chunk, err := mg.GenerateChunk(ChunkCoord{X: cx, Y: cy}, size, scale)
+			if err != nil {
+				t.Fatalf("Expected chunk generation to succeed, got %v", err)
+			}
+
+			for j := 0; j < size; j++ {
+				for i := 0; i < size; i++ {
+					if chunk.At(i, j) != whole.At(cx*size+i, cy*size+j) {
+						t.Fatalf("Expected chunk %d,%d cell %d,%d to match the whole map", cx, cy, i, j)
+					}
+				}
+			}
+		}
+	}
+
+	west, _ := mg.GenerateChunk(ChunkCoord{X: -1, Y: 0}, size, scale)
+	origin, _ := mg.GenerateChunk(ChunkCoord{X: 0, Y: 0}, size, scale)
+	if reflect.DeepEqual(west.Points, origin.Points) {
+		t.Errorf("Expected negative chunk coordinates to cover a different region")
+	}
+}
+
+func TestChunkStore(t *testing.T) {
+	setDomain()
+
+	dir, err := ioutil.TempDir("", "chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mg := &MapGen{Noise: noise.NewWithSeed(1)}
+	s := NewChunkStore(mg, 1, 8, 0.1, 2)
+	s.Backing = ChunkDir(dir)
+
+	a, _ := s.Get(ChunkCoord{X: 0, Y: 0})
+	s.Get(ChunkCoord{X: 1, Y: 0})
+	s.Get(ChunkCoord{X: 0, Y: 0})
+	s.Get(ChunkCoord{X: 2, Y: 0})
+
+	if s.Len() != 2 {
+		t.Errorf("Expected the cache to hold 2 chunks, got %d", s.Len())
+	}
+	if _, ok := s.items[ChunkCoord{X: 1, Y: 0}]; ok {
+		t.Errorf("Expected the least recently used chunk to be evicted")
+	}
+	if _, ok := s.items[ChunkCoord{X: 0, Y: 0}]; !ok {
+		t.Errorf("Expected the recently used chunk to stay cached")
+	}
+
+	// A fresh store reads the chunks back from disk rather than generating them.
+	fresh := NewChunkStore(&MapGen{}, 1, 8, 0.1, 2)
+	fresh.Backing = ChunkDir(dir)
+
+	b, err := fresh.Get(ChunkCoord{X: 0, Y: 0})
+	if err != nil {
+		t.Fatalf("Expected the saved chunk to load, got %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected the loaded chunk to match the generated one")
+	}
+
+	// Stores with another seed, scale or chunk size regenerate the chunk instead of
+	// serving the stored one, and replace it on disk.
+	otherTests := []struct {
+		name string
+		seed int64
+		size int
+		sc   float64
+	}{
+		{"size", 1, 16, 0.1},
+		{"scale", 1, 8, 0.2},
+		{"seed", 2, 8, 0.1},
+	}
+
+	for _, tt := range otherTests {
+		other := NewChunkStore(&MapGen{Noise: noise.NewWithSeed(tt.seed)}, tt.seed, tt.size, tt.sc, 2)
+		other.Backing = ChunkDir(dir)
+
+		m, err := other.Get(ChunkCoord{X: 0, Y: 0})
+		if err != nil {
+			t.Errorf("%s: Expected a stale chunk to be regenerated, got %v", tt.name, err)
+			continue
+		}
+		if m.Grid.X != tt.size || m.Grid.Y != tt.size {
+			t.Errorf("%s: Expected a %dx%d chunk, got %dx%d", tt.name, tt.size, tt.size, m.Grid.X, m.Grid.Y)
+		}
+		if reflect.DeepEqual(a, m) {
+			t.Errorf("%s: Expected the stored chunk not to be served", tt.name)
+		}
+
+		h, _, _, err := ChunkDir(dir).Load(ChunkCoord{X: 0, Y: 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h != other.header() {
+			t.Errorf("%s: Expected the stored header to be replaced with %+v, got %+v", tt.name, other.header(), h)
+		}
+	}
+}
+
+func TestChunkStoreLiteral(t *testing.T) {
+	setDomain()
+
+	s := &ChunkStore{Gen: &MapGen{Noise: noise.NewWithSeed(1)}, Size: 4, SampleScale: 0.1}
+
+	if s.Len() != 0 {
+		t.Errorf("Expected an empty cache, got %d chunks", s.Len())
+	}
+	if _, err := s.Get(ChunkCoord{X: 0, Y: 0}); err != nil {
+		t.Fatalf("Expected a ChunkStore literal to work, got %v", err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected the cache to hold 1 chunk, got %d", s.Len())
+	}
+}
@@ -0,0 +1,251 @@
+package genesis
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	l "github.com/therealfakemoot/genesis/log"
+	noise "github.com/therealfakemoot/genesis/noise"
+	seed "github.com/therealfakemoot/genesis/seed"
+	"math"
+	"strings"
+)
+
+// NewMapGen builds a MapGen from the Terrain section of the loaded configuration.
+// s seeds every noise source the generator needs; secondary layers, such as the
+// Gabor steering field, draw theirs from named children of s in the seed tree. When
+// the configuration defines a module graph under Terrain.Graph, MapGen evaluates its
+// root; otherwise it assembles a single source from the Terrain.Noise,
+// Terrain.Fractal and Terrain.Warp settings. Terrain.Algorithm can replace the noise
+// with one of the grid-based Heightmap generators.
+func NewMapGen(s int64) (*MapGen, error) {
+	mode, err := noise.ParseFractalMode(viper.GetString("Terrain.Fractal.Mode"))
+	if err != nil {
+		return nil, err
+	}
+
+	mg := &MapGen{
+		Stretch: viper.GetFloat64("Terrain.Stretch"),
+		Norm:    viper.GetFloat64("Terrain.Norm"),
+		Workers: viper.GetInt("Terrain.Workers"),
+	}
+
+	mg.Normalization, err = noise.ParseNormalization(viper.GetString("Terrain.Normalization"))
+	if err != nil {
+		return nil, err
+	}
+
+	mg.Lattice, err = noise.ParseLatticeMode(viper.GetString("Terrain.Lattice"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = mg.NoiseConfig().Validate(); err != nil {
+		return nil, err
+	}
+
+	mg.Projection, err = ParseProjection(viper.GetString("Terrain.Projection"))
+	if err != nil {
+		return nil, err
+	}
+
+	if mg.Heightmap, err = newHeightmap(s); err != nil {
+		return nil, err
+	}
+
+	// A module graph replaces the rest of the Terrain settings wholesale.
+	if viper.IsSet("Terrain.Graph.Root") {
+		var spec noise.GraphSpec
+		if err = viper.UnmarshalKey("Terrain.Graph", &spec); err != nil {
+			return nil, err
+		}
+
+		if mg.Noise, err = spec.Build(s); err != nil {
+			return nil, err
+		}
+
+		l.Term.WithFields(logrus.Fields{
+			"root":    spec.Root,
+			"modules": len(spec.Modules),
+		}).Debug("Graph")
+
+		return mg, nil
+	}
+
+	var base noise.Source
+
+	switch algorithm := viper.GetString("Terrain.Noise"); algorithm {
+	case "opensimplex", "":
+		base = noise.NewWithConfig(s, mg.NoiseConfig())
+	default:
+		if base, err = noise.NewSource(algorithm, s); err != nil {
+			return nil, err
+		}
+	}
+
+	if w, ok := base.(*noise.Worley); ok {
+		if w.Metric, err = noise.ParseMetric(viper.GetString("Terrain.Worley.Metric")); err != nil {
+			return nil, err
+		}
+		if w.Return, err = noise.ParseCellReturn(viper.GetString("Terrain.Worley.Return")); err != nil {
+			return nil, err
+		}
+	}
+
+	if g, ok := base.(*noise.Gabor); ok {
+		g.Orientation = viper.GetFloat64("Terrain.Gabor.Orientation") * math.Pi / 180
+		g.Frequency = viper.GetFloat64("Terrain.Gabor.Frequency")
+		g.Bandwidth = viper.GetFloat64("Terrain.Gabor.Bandwidth")
+		g.Impulses = viper.GetInt("Terrain.Gabor.Impulses")
+
+		// The steering layer takes its own branch of the seed tree, so it stays
+		// independent of the stripes.
+		if steer := viper.GetString("Terrain.Gabor.Steer"); steer != "" {
+			field, err := noise.NewSource(steer, seed.New(s).Child("gabor/steer").Seed())
+			if err != nil {
+				return nil, err
+			}
+
+			freq := viper.GetFloat64("Terrain.Gabor.SteerFrequency")
+			g.OrientationField = &noise.ScaleInput{Source: field, X: freq, Y: freq, Z: freq, W: freq}
+		}
+
+		l.Term.WithFields(logrus.Fields{
+			"orientation": viper.GetFloat64("Terrain.Gabor.Orientation"),
+			"frequency":   g.Frequency,
+			"bandwidth":   g.Bandwidth,
+			"impulses":    g.Impulses,
+			"steer":       viper.GetString("Terrain.Gabor.Steer"),
+		}).Debug("Gabor")
+	}
+
+	f := noise.NewFractal(base)
+	f.Mode = mode
+	f.Octaves = viper.GetInt("Terrain.Fractal.Octaves")
+	f.Lacunarity = viper.GetFloat64("Terrain.Fractal.Lacunarity")
+	f.Persistence = viper.GetFloat64("Terrain.Fractal.Persistence")
+	f.Frequency = viper.GetFloat64("Terrain.Fractal.Frequency")
+	f.Offset = viper.GetFloat64("Terrain.Fractal.Offset")
+	f.Gain = viper.GetFloat64("Terrain.Fractal.Gain")
+
+	l.Term.WithFields(logrus.Fields{
+		"stretch":       mg.Stretch,
+		"norm":          mg.Norm,
+		"normalization": mg.Normalization,
+		"lattice":       mg.Lattice,
+	}).Debug("Lattice")
+
+	l.Term.WithFields(logrus.Fields{
+		"noise":       viper.GetString("Terrain.Noise"),
+		"mode":        f.Mode,
+		"octaves":     f.Octaves,
+		"lacunarity":  f.Lacunarity,
+		"persistence": f.Persistence,
+		"frequency":   f.Frequency,
+		"offset":      f.Offset,
+		"gain":        f.Gain,
+	}).Debug("Fractal")
+
+	var src noise.Source = f
+
+	if strength := viper.GetFloat64("Terrain.Warp.Strength"); strength != 0 {
+		w := noise.NewWarp(f, strength)
+		w.Depth = viper.GetInt("Terrain.Warp.Depth")
+
+		l.Term.WithFields(logrus.Fields{
+			"strength": w.Strength,
+			"depth":    w.Depth,
+		}).Debug("Warp")
+
+		src = w
+	}
+
+	mg.Noise = src
+
+	return mg, nil
+}
+
+// newHeightmap returns the Heightmap selected by Terrain.Algorithm, or nil when the
+// terrain should be sampled from noise.
+func newHeightmap(seed int64) (Heightmap, error) {
+	algorithm, err := ParseAlgorithm(viper.GetString("Terrain.Algorithm"))
+	if err != nil {
+		return nil, err
+	}
+
+	var h Heightmap
+
+	switch algorithm {
+	case DiamondSquareAlgorithm:
+		h = DiamondSquare{Seed: seed, Roughness: viper.GetFloat64("Terrain.Roughness")}
+	case FaultLinesAlgorithm:
+		h = FaultLines{Seed: seed, Faults: viper.GetInt("Terrain.Faults")}
+	case ParticleDepositionAlgorithm:
+		h = ParticleDeposition{Seed: seed, Particles: viper.GetInt("Terrain.Particles"), Drops: viper.GetInt("Terrain.Drops")}
+	case SpectralAlgorithm:
+		h = SpectralSynthesis{Seed: seed, Exponent: viper.GetFloat64("Terrain.Exponent")}
+	default:
+		return nil, nil
+	}
+
+	l.Term.WithFields(logrus.Fields{
+		"algorithm": algorithm,
+		"settings":  fmt.Sprintf("%+v", h),
+	}).Debug("Heightmap")
+
+	return h, nil
+}
+
+// NewPasses returns the post-processing passes listed, comma separated and in order,
+// by Terrain.Erosion.Passes, each configured from its own Terrain.Erosion section.
+// Every pass draws its seed from its name and place in the list, so adding a pass
+// to the end never changes what the ones before it do. Passes may repeat, so
+// "hydraulic,thermal,hydraulic" alternates water and weathering.
+func NewPasses(s int64) ([]Pass, error) {
+	var passes []Pass
+
+	for _, name := range strings.Split(viper.GetString("Terrain.Erosion.Passes"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		passSeed := seed.New(s).Child(fmt.Sprintf("%d/%s", len(passes), name)).Seed()
+
+		var p Pass
+
+		switch name {
+		case "hydraulic":
+			h := NewHydraulicErosion(passSeed)
+			h.Iterations = viper.GetInt("Terrain.Erosion.Hydraulic.Iterations")
+			h.Rain = viper.GetFloat64("Terrain.Erosion.Hydraulic.Rain")
+			h.Evaporation = viper.GetFloat64("Terrain.Erosion.Hydraulic.Evaporation")
+			h.Capacity = viper.GetFloat64("Terrain.Erosion.Hydraulic.Capacity")
+			h.Erosion = viper.GetFloat64("Terrain.Erosion.Hydraulic.Erosion")
+			h.Deposition = viper.GetFloat64("Terrain.Erosion.Hydraulic.Deposition")
+			h.Inertia = viper.GetFloat64("Terrain.Erosion.Hydraulic.Inertia")
+			h.Gravity = viper.GetFloat64("Terrain.Erosion.Hydraulic.Gravity")
+			h.MinSlope = viper.GetFloat64("Terrain.Erosion.Hydraulic.MinSlope")
+			h.Lifetime = viper.GetInt("Terrain.Erosion.Hydraulic.Lifetime")
+			h.Radius = viper.GetInt("Terrain.Erosion.Hydraulic.Radius")
+			p = h
+		case "thermal":
+			t := NewThermalErosion()
+			t.Iterations = viper.GetInt("Terrain.Erosion.Thermal.Iterations")
+			t.Talus = viper.GetFloat64("Terrain.Erosion.Thermal.Talus")
+			t.Rate = viper.GetFloat64("Terrain.Erosion.Thermal.Rate")
+			p = t
+		default:
+			return nil, fmt.Errorf("unknown terrain pass %q", name)
+		}
+
+		l.Term.WithFields(logrus.Fields{
+			"pass":     name,
+			"settings": fmt.Sprintf("%+v", p),
+		}).Debug("Pass")
+
+		passes = append(passes, p)
+	}
+
+	return passes, nil
+}
@@ -0,0 +1,273 @@
+package genesis
+
+import (
+	"math"
+
+	seed "github.com/therealfakemoot/genesis/seed"
+)
+
+// Pass is a stage that reworks a finished Map in place, such as an erosion
+// simulation. Passes run in sequence after Generate, and the same pass on the same
+// Map always gives the same result.
+type Pass interface {
+	Apply(m Map)
+}
+
+// HydraulicErosion simulates rainfall wearing the terrain down, one droplet at a
+// time, after Hans Theobald Beyer's "Implementation of a method for hydraulic
+// erosion". Each droplet lands somewhere at random and runs downhill, picking up
+// sediment where it speeds up and dropping it where it slows down or its load
+// exceeds what it can carry, until it evaporates or leaves the map. The result is
+// branching gullies on the slopes and smooth, silted valley floors.
+type HydraulicErosion struct {
+	Seed int64
+
+	// Iterations is the number of droplets simulated.
+	Iterations int
+	// Rain is the water each droplet starts with.
+	Rain float64
+	// Evaporation is the fraction of its water a droplet loses at each step.
+	Evaporation float64
+	// Capacity scales how much sediment a droplet can carry for its speed, water and
+	// the steepness of its path.
+	Capacity float64
+	// Erosion and Deposition are the fractions of the gap between a droplet's load
+	// and its capacity that it picks up or drops at each step.
+	Erosion    float64
+	Deposition float64
+	// Inertia is how much a droplet keeps its direction rather than turning
+	// downhill: 0 follows the slope exactly, 1 never turns.
+	Inertia float64
+
+	// Gravity accelerates droplets downhill. MinSlope stops droplets on flat ground
+	// losing all capacity. Lifetime caps the steps a droplet takes, and Radius is the
+	// size of the area each erosion step draws material from.
+	Gravity  float64
+	MinSlope float64
+	Lifetime int
+	Radius   int
+}
+
+// NewHydraulicErosion returns a HydraulicErosion with the customary settings.
+func NewHydraulicErosion(seed int64) *HydraulicErosion {
+	return &HydraulicErosion{
+		Seed:        seed,
+		Iterations:  50000,
+		Rain:        1,
+		Evaporation: 0.01,
+		Capacity:    4,
+		Erosion:     0.3,
+		Deposition:  0.3,
+		Inertia:     0.05,
+		Gravity:     4,
+		MinSlope:    0.01,
+		Lifetime:    30,
+		Radius:      3,
+	}
+}
+
+// Apply runs the simulation on m.
+func (h *HydraulicErosion) Apply(m Map) {
+	width, height := m.Grid.X, m.Grid.Y
+	if width < 2 || height < 2 {
+		return
+	}
+
+	rng := seed.NewStream(h.Seed)
+
+	for i := 0; i < h.Iterations; i++ {
+		x, y := rng.Float64()*float64(width-1), rng.Float64()*float64(height-1)
+		var dx, dy, sediment float64
+		speed, water := 1.0, h.Rain
+
+		for step := 0; step < h.Lifetime; step++ {
+			cellX, cellY := int(x), int(y)
+
+			z, gx, gy := surface(m, x, y)
+
+			// Turn towards the downhill direction, keeping some of the old heading.
+			dx = dx*h.Inertia - gx*(1-h.Inertia)
+			dy = dy*h.Inertia - gy*(1-h.Inertia)
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				break
+			}
+			dx, dy = dx/l, dy/l
+
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= float64(width-1) || ny < 0 || ny >= float64(height-1) {
+				break
+			}
+
+			nz, _, _ := surface(m, nx, ny)
+			dz := nz - z
+
+			capacity := math.Max(-dz, h.MinSlope) * speed * water * h.Capacity
+
+			if sediment > capacity || dz > 0 {
+				// Uphill, fill the pit behind the droplet as far as its load allows;
+				// otherwise drop the excess.
+				var amount float64
+				if dz > 0 {
+					amount = math.Min(dz, sediment)
+				} else {
+					amount = (sediment - capacity) * h.Deposition
+				}
+				sediment -= amount
+				deposit(m, x, y, amount)
+			} else {
+				// Never dig deeper than the drop to the next position, or the droplet
+				// would carve a pit behind itself.
+				amount := math.Min((capacity-sediment)*h.Erosion, -dz)
+				sediment += erode(m, cellX, cellY, h.Radius, amount)
+			}
+
+			speed = math.Sqrt(math.Max(0, speed*speed-dz*h.Gravity))
+			water *= 1 - h.Evaporation
+			x, y = nx, ny
+		}
+	}
+}
+
+// surface returns the height and gradient of m at x,y by bilinear interpolation of
+// the four cells around it. x and y must lie within [0, size-1).
+func surface(m Map, x, y float64) (z, gx, gy float64) {
+	i, j := int(x), int(y)
+	u, v := x-float64(i), y-float64(j)
+
+	nw, ne := m.At(i, j), m.At(i+1, j)
+	sw, se := m.At(i, j+1), m.At(i+1, j+1)
+
+	gx = (ne-nw)*(1-v) + (se-sw)*v
+	gy = (sw-nw)*(1-u) + (se-ne)*u
+	z = nw*(1-u)*(1-v) + ne*u*(1-v) + sw*(1-u)*v + se*u*v
+
+	return z, gx, gy
+}
+
+// deposit spreads amount over the four cells around x,y, weighted by proximity.
+func deposit(m Map, x, y, amount float64) {
+	i, j := int(x), int(y)
+	u, v := x-float64(i), y-float64(j)
+
+	m.Set(i, j, m.At(i, j)+amount*(1-u)*(1-v))
+	m.Set(i+1, j, m.At(i+1, j)+amount*u*(1-v))
+	m.Set(i, j+1, m.At(i, j+1)+amount*(1-u)*v)
+	m.Set(i+1, j+1, m.At(i+1, j+1)+amount*u*v)
+}
+
+// erode removes amount from the cells within radius of i,j, taking more from the
+// nearer ones, and returns the amount removed.
+func erode(m Map, i, j, radius int, amount float64) float64 {
+	var total float64
+	for y := j - radius; y <= j+radius; y++ {
+		for x := i - radius; x <= i+radius; x++ {
+			if x >= 0 && x < m.Grid.X && y >= 0 && y < m.Grid.Y {
+				total += math.Max(0, float64(radius)-math.Hypot(float64(x-i), float64(y-j)))
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	for y := j - radius; y <= j+radius; y++ {
+		for x := i - radius; x <= i+radius; x++ {
+			if x >= 0 && x < m.Grid.X && y >= 0 && y < m.Grid.Y {
+				w := math.Max(0, float64(radius)-math.Hypot(float64(x-i), float64(y-j)))
+				m.Set(x, y, m.At(x, y)-amount*w/total)
+			}
+		}
+	}
+
+	return amount
+}
+
+// ThermalErosion simulates weathering: wherever the ground is steeper than the talus
+// angle, loose material slides down onto the lower neighbours until the slope settles
+// back to it. Cliffs soften into scree slopes while gentle ground is left alone. It
+// involves no randomness and conserves material exactly.
+type ThermalErosion struct {
+	// Iterations is the number of times material is moved across the whole map.
+	Iterations int
+	// Talus is the steepest stable slope, as the height difference between
+	// neighbouring cells one cell apart: the tangent of the talus angle in map units.
+	Talus float64
+	// Rate is the fraction of the excess above the talus slope moved in each
+	// iteration, from 0 to 1.
+	Rate float64
+}
+
+// NewThermalErosion returns a ThermalErosion with the customary settings.
+func NewThermalErosion() *ThermalErosion {
+	return &ThermalErosion{
+		Iterations: 50,
+		Talus:      0.01,
+		Rate:       0.5,
+	}
+}
+
+// thermalNeighbours are the offsets of the eight cells around a cell and their
+// distances from it.
+var thermalNeighbours = [8]struct {
+	x, y int
+	d    float64
+}{
+	{-1, -1, math.Sqrt2}, {0, -1, 1}, {1, -1, math.Sqrt2},
+	{-1, 0, 1}, {1, 0, 1},
+	{-1, 1, math.Sqrt2}, {0, 1, 1}, {1, 1, math.Sqrt2},
+}
+
+// Apply runs the simulation on m. Every cell's movement in an iteration is worked out
+// from the heights at its start, so the result does not depend on scan order.
+func (t *ThermalErosion) Apply(m Map) {
+	width, height := m.Grid.X, m.Grid.Y
+	delta := make([]float64, len(m.Points))
+
+	var excess [8]float64
+
+	for i := 0; i < t.Iterations; i++ {
+		for j := range delta {
+			delta[j] = 0
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				z := m.At(x, y)
+
+				var total, most float64
+				for n, o := range thermalNeighbours {
+					excess[n] = 0
+
+					nx, ny := x+o.x, y+o.y
+					if nx < 0 || nx >= width || ny < 0 || ny >= height {
+						continue
+					}
+
+					if e := z - m.At(nx, ny) - t.Talus*o.d; e > 0 {
+						excess[n] = e
+						total += e
+						most = math.Max(most, e)
+					}
+				}
+				if total == 0 {
+					continue
+				}
+
+				// Moving half the steepest excess at most levels that slope exactly,
+				// rather than overshooting into a pit the other way.
+				moved := t.Rate * most / 2
+				delta[y*width+x] -= moved
+				for n, o := range thermalNeighbours {
+					if excess[n] > 0 {
+						delta[(y+o.y)*width+x+o.x] += moved * excess[n] / total
+					}
+				}
+			}
+		}
+
+		for j, d := range delta {
+			m.Points[j] += d
+		}
+	}
+}
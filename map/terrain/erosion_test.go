@@ -0,0 +1,87 @@
+package genesis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func erosionTestMap() Map {
+	m := NewMap(Grid{X: 64, Y: 64})
+	copy(m.Points, SpectralSynthesis{Seed: 1, Exponent: 2}.Heights(64, 64))
+	return m
+}
+
+func sum(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+func TestHydraulicErosion(t *testing.T) {
+	before := erosionTestMap()
+
+	erode := func(seed int64) Map {
+		m := erosionTestMap()
+		h := NewHydraulicErosion(seed)
+		h.Iterations = 2000
+		h.Apply(m)
+		return m
+	}
+
+	m := erode(1)
+
+	if reflect.DeepEqual(m.Points, before.Points) {
+		t.Fatal("Expected erosion to change the map")
+	}
+	for i, v := range m.Points {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatalf("Expected finite heights, got %v at %d", v, i)
+		}
+	}
+
+	// Droplets only move material around, or carry it off the edge of the map.
+	if after := sum(m.Points); after > sum(before.Points)+1e-9 {
+		t.Errorf("Expected erosion not to add material, got %v from %v", after, sum(before.Points))
+	}
+
+	if !reflect.DeepEqual(m.Points, erode(1).Points) {
+		t.Error("Expected the same seed to erode the same way")
+	}
+	if reflect.DeepEqual(m.Points, erode(2).Points) {
+		t.Error("Expected different seeds to erode differently")
+	}
+}
+
+func TestThermalErosion(t *testing.T) {
+	// A single cliff between two plateaus.
+	m := NewMap(Grid{X: 16, Y: 4})
+	for y := 0; y < 4; y++ {
+		for x := 8; x < 16; x++ {
+			m.Set(x, y, 1)
+		}
+	}
+	before := sum(m.Points)
+
+	steepest := func() float64 {
+		var s float64
+		for y := 0; y < m.Grid.Y; y++ {
+			for x := 1; x < m.Grid.X; x++ {
+				s = math.Max(s, math.Abs(m.At(x, y)-m.At(x-1, y)))
+			}
+		}
+		return s
+	}
+
+	thermal := &ThermalErosion{Iterations: 500, Talus: 0.1, Rate: 0.5}
+	thermal.Apply(m)
+
+	if s := steepest(); s > 0.15 {
+		t.Errorf("Expected the cliff to settle near the talus slope, got %v", s)
+	}
+	if after := sum(m.Points); math.Abs(after-before) > 1e-9 {
+		t.Errorf("Expected thermal erosion to conserve material, got %v from %v", after, before)
+	}
+}
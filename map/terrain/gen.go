@@ -7,22 +7,136 @@ import (
 	l "github.com/therealfakemoot/genesis/log"
 	noise "github.com/therealfakemoot/genesis/noise"
 	Q "github.com/therealfakemoot/go-quantize"
+	"math"
 )
 
 // MapGen will allow for reuse and iterative tweaking of noise generation
 // parameters.
+//
+// Stretch, Norm, Normalization and Lattice are passed through to the
+// OpenSimplex lattice by NoiseConfig; they have no effect on other noise algorithms.
+//
+// When Heightmap is set, Generate takes its heights from it instead of sampling Noise.
+// Noise is still used by layers that need a continuous field, such as GenerateCurl.
+//
+// Workers sets how many goroutines generate a map; 0 uses one per CPU.
 type MapGen struct {
-	Stretch float64
-	Squish  float64
-	Noise   *noise.Noise
+	Stretch       float64
+	Norm          float64
+	Normalization noise.Normalization
+	Lattice       noise.LatticeMode
+	Projection    Projection
+	Noise         noise.Source
+	Heightmap     Heightmap
+	Workers       int
+}
+
+// NoiseConfig returns the OpenSimplex configuration described by mg's fields. Zero
+// fields fall back to the reference constants, and Squish is derived from Stretch.
+func (mg *MapGen) NoiseConfig() noise.Config {
+	c := noise.DefaultConfig()
+
+	if mg.Stretch != 0 {
+		c.Stretch = mg.Stretch
+	}
+	if mg.Norm != 0 {
+		c.Norm = mg.Norm
+	}
+	if mg.Normalization != "" {
+		c.Normalization = mg.Normalization
+	}
+	if mg.Lattice != "" {
+		c.Lattice = mg.Lattice
+	}
+	c.Squish = noise.SquishFor(c.Stretch)
+
+	return c
+}
+
+// Projection selects how Generate maps the cells of a Map onto noise space.
+type Projection string
+
+const (
+	// Flat samples the plane z = 0, one cell every sampleScale units.
+	Flat Projection = "flat"
+	// Sphere samples the surface of a sphere, treating the Map as an
+	// equirectangular projection: columns are longitude and rows latitude, with
+	// row 0 at the north pole.
+	Sphere Projection = "sphere"
+)
+
+// ParseProjection returns the Projection with the given name.
+func ParseProjection(name string) (Projection, error) {
+	switch p := Projection(name); p {
+	case Flat, Sphere:
+		return p, nil
+	case "":
+		return Flat, nil
+	}
+
+	return "", fmt.Errorf("unknown projection %q", name)
 }
 
 // Generate takes x,y coordinates indicating the maximum dimensions of the
-// terrain map to be generated.
+// terrain map to be generated. The cells are laid out according to mg.Projection.
+//
+// A Heightmap generator works on the grid directly, so it ignores sampleScale and the
+// projection.
 func (mg *MapGen) Generate(x, y, sampleScale, thresholdScale float64) Map {
-	m := Map{}
-	m.Grid = Grid{X: int(x), Y: int(y), Z: 0}
-	points := make([][]float64, int(y))
+	if mg.Heightmap != nil {
+		width := int(x)
+		heights := mg.Heightmap.Heights(width, int(y))
+
+		return mg.generate(x, y, func(xGen, yGen float64) float64 {
+			return heights[int(yGen)*width+int(xGen)]
+		})
+	}
+
+	if mg.Projection == Sphere {
+		return mg.GenerateSphere(x, y, sampleScale, thresholdScale)
+	}
+
+	return mg.generate(x, y, func(xGen, yGen float64) float64 {
+		return mg.Noise.Eval3(xGen*sampleScale, yGen*sampleScale, 0)
+	})
+}
+
+// GenerateSphere produces an equirectangular map of a whole planet. Each cell is
+// sampled with Eval3 at its latitude and longitude on the surface of a sphere whose
+// equator is x*sampleScale units around, so features at the equator are the same
+// size as on a flat map with the same sampleScale.
+//
+// Because the noise is sampled in three dimensions the left and right edges of the
+// map join seamlessly, and features near the poles are stretched horizontally only
+// by the projection itself rather than pinched by the sampling. Rows are sampled at
+// their centres, so no row lies exactly on a pole. Row 0 is the northernmost, as in
+// the usual equirectangular layout, and column 0 is longitude 0.
+func (mg *MapGen) GenerateSphere(x, y, sampleScale, thresholdScale float64) Map {
+	radius := x * sampleScale / (2 * math.Pi)
+
+	return mg.generate(x, y, func(xGen, yGen float64) float64 {
+		lat := math.Pi/2 - math.Pi*(yGen+0.5)/y
+		lon := 2 * math.Pi * xGen / x
+
+		return mg.Noise.Eval3(
+			radius*math.Cos(lat)*math.Cos(lon),
+			radius*math.Cos(lat)*math.Sin(lon),
+			radius*math.Sin(lat),
+		)
+	})
+}
+
+// traceRows is the number of rows, spread evenly down the map, whose raw and
+// quantized values are logged at debug level.
+const traceRows = 8
+
+// generate fills an x by y Map with the quantized output of sample.
+//
+// The rows are spread over mg.Workers goroutines by noise.FillRows. Each row is
+// sampled and quantized on its own, so the output is identical whatever the number of
+// workers. sample must be safe for concurrent use, which every noise.Source is.
+func (mg *MapGen) generate(x, y float64, sample func(xGen, yGen float64) float64) Map {
+	m := NewMap(Grid{X: int(x), Y: int(y), Z: 0})
 
 	d := Q.Domain{
 		Max:  viper.GetFloat64("Terrain.Domain.Max"),
@@ -31,25 +145,31 @@ func (mg *MapGen) Generate(x, y, sampleScale, thresholdScale float64) Map {
 	}
 
 	l.Term.WithFields(logrus.Fields{
-		"domain": fmt.Sprintf("%+v", d),
+		"domain":  fmt.Sprintf("%+v", d),
+		"workers": mg.Workers,
 	}).Debug("Domain")
 
-	for yGen := 0.0; yGen < y; yGen++ {
-		row := make([]float64, int(x))
-		for xGen := 0.0; xGen < x; xGen++ {
-			row[int(xGen)] = mg.Noise.Eval3(xGen*sampleScale, yGen*sampleScale, 0)
+	traceEvery := m.Grid.Y / traceRows
+	if traceEvery < 1 {
+		traceEvery = 1
+	}
+	trace := l.Term.Level >= logrus.DebugLevel
+
+	noise.FillRows(m.Points, m.Grid.X, m.Grid.Y, mg.Workers, func(j int, row []float64) {
+		for i := range row {
+			row[i] = sample(float64(i), float64(j))
 		}
 		quantized := d.Quantize(row)
 
-		points[int(yGen)] = quantized
-
-		l.Term.WithFields(logrus.Fields{
-			"Raw Row":       row,
-			"Quantized Row": quantized,
-		}).Debug(fmt.Sprintf("Row %0.f", yGen))
-	}
+		if trace && j%traceEvery == 0 {
+			l.Term.WithFields(logrus.Fields{
+				"Raw Row":       row,
+				"Quantized Row": quantized,
+			}).Debug(fmt.Sprintf("Row %d", j))
+		}
 
-	m.Points = points
+		copy(row, quantized)
+	})
 
 	return m
 }
@@ -0,0 +1,84 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/spf13/viper"
+	noise "github.com/therealfakemoot/genesis/noise"
+)
+
+func setDomain() {
+	viper.Set("Terrain.Domain.Min", -1.0)
+	viper.Set("Terrain.Domain.Max", 1.0)
+	viper.Set("Terrain.Domain.Step", 0.01)
+}
+
+func TestGenerateWorkers(t *testing.T) {
+	setDomain()
+
+	mg := &MapGen{Noise: noise.NewFractal(noise.NewWithSeed(18006665432)), Workers: 1}
+	want := mg.Generate(97, 61, 0.05, 10)
+
+	for _, workers := range []int{2, 7, 0} {
+		mg.Workers = workers
+		got := mg.Generate(97, 61, 0.05, 10)
+
+		for i := range want.Points {
+			if math.Float64bits(got.Points[i]) != math.Float64bits(want.Points[i]) {
+				t.Fatalf("Expected identical output with %d workers, cell %d differs: %v and %v", workers, i, got.Points[i], want.Points[i])
+			}
+		}
+	}
+}
+
+// BenchmarkGenerate4k shows how generation scales with workers; the speedup is
+// bounded by GOMAXPROCS.
+func BenchmarkGenerate4k(b *testing.B) {
+	setDomain()
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			mg := &MapGen{Noise: noise.NewWithSeed(18006665432), Workers: workers}
+
+			for i := 0; i < b.N; i++ {
+				mg.Generate(4096, 4096, 0.01, 10)
+			}
+		})
+	}
+}
+
+// axisSource returns one of its coordinates, scaled by sign.
+type axisSource struct {
+	axis int
+	sign float64
+}
+
+func (a axisSource) Eval2(x, y float64) float64 { return a.Eval3(x, y, 0) }
+
+func (a axisSource) Eval3(x, y, z float64) float64 {
+	return a.sign * [3]float64{x, y, z}[a.axis]
+}
+
+func TestSphereOrientation(t *testing.T) {
+	setDomain()
+
+	// On the sphere z runs from the south pole to the north pole.
+	mg := &MapGen{Noise: axisSource{axis: 2, sign: 1}, Projection: Sphere}
+	m := mg.Generate(32, 16, 0.1, 10)
+
+	if north, south := m.At(0, 0), m.At(0, 15); north <= south {
+		t.Errorf("Expected row 0 to be the north pole, got %v at the top and %v at the bottom", north, south)
+	}
+
+	// A stream function rising northward should flow the same way as one rising
+	// towards row 0 on a flat map.
+	flat := (&MapGen{Noise: axisSource{axis: 1, sign: -1}}).GenerateCurl(32, 16, 0.1)
+	sphere := mg.GenerateCurl(32, 16, 0.1)
+
+	f, s := flat.At(5, 8), sphere.At(5, 8)
+	if f.X >= 0 || s.X >= 0 || math.Abs(s.Y) > 1e-6 {
+		t.Errorf("Expected both fields to flow in -X, got %+v flat and %+v on the sphere", f, s)
+	}
+}
@@ -0,0 +1,350 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	seed "github.com/therealfakemoot/genesis/seed"
+)
+
+// Algorithm selects how MapGen builds a height map.
+type Algorithm string
+
+const (
+	// NoiseAlgorithm samples MapGen.Noise at every cell.
+	NoiseAlgorithm Algorithm = "noise"
+	// DiamondSquareAlgorithm uses DiamondSquare.
+	DiamondSquareAlgorithm Algorithm = "diamond-square"
+	// FaultLinesAlgorithm uses FaultLines.
+	FaultLinesAlgorithm Algorithm = "fault-lines"
+	// ParticleDepositionAlgorithm uses ParticleDeposition.
+	ParticleDepositionAlgorithm Algorithm = "particle-deposition"
+	// SpectralAlgorithm uses SpectralSynthesis.
+	SpectralAlgorithm Algorithm = "spectral"
+)
+
+// Algorithms lists the names accepted by ParseAlgorithm.
+var Algorithms = []Algorithm{NoiseAlgorithm, DiamondSquareAlgorithm, FaultLinesAlgorithm, ParticleDepositionAlgorithm, SpectralAlgorithm}
+
+// ParseAlgorithm returns the Algorithm with the given name.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range Algorithms {
+		if Algorithm(name) == a {
+			return a, nil
+		}
+	}
+	if name == "" {
+		return NoiseAlgorithm, nil
+	}
+
+	return "", fmt.Errorf("unknown terrain algorithm %q", name)
+}
+
+// Heightmap is implemented by generators that build a whole grid of heights at once,
+// rather than sampling a continuous Source cell by cell. Heights returns width*height
+// values in row-major order, rescaled onto [-1, 1] so they quantize the same way as
+// noise. The same generator always returns the same heights for the same size.
+type Heightmap interface {
+	Heights(width, height int) []float64
+}
+
+// DiamondSquare is Fournier, Fussell and Carpenter's midpoint displacement. It works
+// on a square grid of 2^n+1 cells covering the map, repeatedly setting the centres
+// of squares and diamonds to the average of their corners plus a random offset that
+// shrinks by 2^-Roughness at each level. Roughness between 0 and 1 is customary;
+// lower values give rougher terrain.
+type DiamondSquare struct {
+	Seed      int64
+	Roughness float64
+}
+
+// Heights returns the diamond-square height map cropped to width x height.
+func (d DiamondSquare) Heights(width, height int) []float64 {
+	n := 1
+	for n+1 < width || n+1 < height {
+		n *= 2
+	}
+	size := n + 1
+
+	rng := seed.NewStream(d.Seed)
+	jitter := func(scale float64) float64 { return (2*rng.Float64() - 1) * scale }
+
+	grid := make([]float64, size*size)
+	at := func(x, y int) *float64 { return &grid[y*size+x] }
+
+	*at(0, 0), *at(n, 0), *at(0, n), *at(n, n) = jitter(1), jitter(1), jitter(1), jitter(1)
+
+	scale := 1.0
+	for step := n; step > 1; step /= 2 {
+		half := step / 2
+
+		// Diamond step: the centre of every square.
+		for y := half; y < size; y += step {
+			for x := half; x < size; x += step {
+				avg := (*at(x-half, y-half) + *at(x+half, y-half) + *at(x-half, y+half) + *at(x+half, y+half)) / 4
+				*at(x, y) = avg + jitter(scale)
+			}
+		}
+
+		// Square step: the midpoint of every edge, from whichever of its four
+		// neighbours lie on the grid.
+		for y := 0; y < size; y += half {
+			for x := (y + half) % step; x < size; x += step {
+				var sum float64
+				var count int
+				for _, o := range [][2]int{{-half, 0}, {half, 0}, {0, -half}, {0, half}} {
+					nx, ny := x+o[0], y+o[1]
+					if nx >= 0 && nx < size && ny >= 0 && ny < size {
+						sum += *at(nx, ny)
+						count++
+					}
+				}
+				*at(x, y) = sum/float64(count) + jitter(scale)
+			}
+		}
+
+		scale *= math.Pow(2, -d.Roughness)
+	}
+
+	heights := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		copy(heights[y*width:(y+1)*width], grid[y*size:y*size+width])
+	}
+
+	return rescale(heights)
+}
+
+// FaultLines builds terrain by cutting the map along Faults random straight lines,
+// raising the land on one side of each and lowering it on the other. Later faults
+// move the land less than earlier ones, so the largest features are laid down first.
+type FaultLines struct {
+	Seed   int64
+	Faults int
+}
+
+// Heights returns the fault line height map.
+func (f FaultLines) Heights(width, height int) []float64 {
+	rng := seed.NewStream(f.Seed)
+	heights := make([]float64, width*height)
+
+	for i := 0; i < f.Faults; i++ {
+		px, py := rng.Float64()*float64(width), rng.Float64()*float64(height)
+		sin, cos := math.Sincos(2 * math.Pi * rng.Float64())
+		shift := 1 - float64(i)/float64(f.Faults)
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if (float64(x)-px)*sin-(float64(y)-py)*cos > 0 {
+					heights[y*width+x] += shift
+				} else {
+					heights[y*width+x] -= shift
+				}
+			}
+		}
+	}
+
+	return rescale(heights)
+}
+
+// ParticleDeposition builds terrain by dropping Particles grains of material, one
+// at a time, and letting each roll downhill until it comes to rest on a cell no
+// more than one grain higher than all its neighbours. Grains are dropped from Drops
+// vents that wander in a random walk, which grows chains of rounded, volcano-like
+// hills.
+type ParticleDeposition struct {
+	Seed      int64
+	Particles int
+	Drops     int
+}
+
+// Heights returns the particle deposition height map.
+func (p ParticleDeposition) Heights(width, height int) []float64 {
+	rng := seed.NewStream(p.Seed)
+	heights := make([]float64, width*height)
+	if width == 0 || height == 0 {
+		return heights
+	}
+
+	drops := p.Drops
+	if drops < 1 {
+		drops = 1
+	}
+
+	neighbours := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+	for d := 0; d < drops; d++ {
+		vx, vy := rng.Intn(width), rng.Intn(height)
+
+		// The last vent drops the grains that don't divide evenly between them.
+		grains := p.Particles / drops
+		if d == drops-1 {
+			grains += p.Particles % drops
+		}
+
+		for i := 0; i < grains; i++ {
+			x, y := vx, vy
+
+			// A grain can only ever move downhill, so it must stop within as many
+			// steps as there are cells.
+			for steps := 0; steps < width*height; steps++ {
+				bx, by := x, y
+				for _, o := range neighbours {
+					nx, ny := x+o[0], y+o[1]
+					if nx >= 0 && nx < width && ny >= 0 && ny < height && heights[ny*width+nx] < heights[by*width+bx] {
+						bx, by = nx, ny
+					}
+				}
+				if heights[y*width+x]-heights[by*width+bx] <= 1 {
+					break
+				}
+				x, y = bx, by
+			}
+			heights[y*width+x]++
+
+			o := neighbours[rng.Intn(len(neighbours))]
+			vx = clamp(vx+o[0], 0, width-1)
+			vy = clamp(vy+o[1], 0, height-1)
+		}
+	}
+
+	return rescale(heights)
+}
+
+// SpectralSynthesis builds terrain in the frequency domain: it gives every spatial
+// frequency f a random phase and an amplitude proportional to f^(-Exponent/2), so the
+// power spectrum falls off as 1/f^Exponent, then transforms it back with an inverse
+// FFT. Higher exponents give smoother terrain; values around 2 resemble real
+// landscapes.
+//
+// The FFT works on a power-of-two square grid and treats it as periodic, so a map of
+// that size tiles seamlessly. Other sizes are cropped from the next one up and don't.
+type SpectralSynthesis struct {
+	Seed     int64
+	Exponent float64
+}
+
+// Heights returns the spectral synthesis height map cropped to width x height.
+func (s SpectralSynthesis) Heights(width, height int) []float64 {
+	n := 1
+	for n < width || n < height {
+		n *= 2
+	}
+
+	rng := seed.NewStream(s.Seed)
+	grid := make([]complex128, n*n)
+
+	for v := 0; v < n; v++ {
+		for u := 0; u < n; u++ {
+			fu, fv := float64(u), float64(v)
+			if u > n/2 {
+				fu -= float64(n)
+			}
+			if v > n/2 {
+				fv -= float64(n)
+			}
+
+			f := math.Hypot(fu, fv)
+			if f == 0 {
+				continue
+			}
+
+			// A Gaussian amplitude with a uniform phase gives a Gaussian surface.
+			r := math.Sqrt(-2*math.Log(1-rng.Float64())) * math.Pow(f, -s.Exponent/2)
+			grid[v*n+u] = cmplx.Rect(r, 2*math.Pi*rng.Float64())
+		}
+	}
+
+	fft2(grid, n, true)
+
+	heights := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			heights[y*width+x] = real(grid[y*n+x])
+		}
+	}
+
+	return rescale(heights)
+}
+
+// fft2 transforms an n x n grid in place, n being a power of two.
+func fft2(grid []complex128, n int, inverse bool) {
+	col := make([]complex128, n)
+
+	for y := 0; y < n; y++ {
+		fft(grid[y*n:(y+1)*n], inverse)
+	}
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			col[y] = grid[y*n+x]
+		}
+		fft(col, inverse)
+		for y := 0; y < n; y++ {
+			grid[y*n+x] = col[y]
+		}
+	}
+}
+
+// fft is an iterative radix-2 Cooley-Tukey transform of a, whose length must be a
+// power of two. The inverse transform is left unscaled; rescale takes care of that.
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			t := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := a[start+k], a[start+k+size/2]*t
+				a[start+k], a[start+k+size/2] = even+odd, even-odd
+				t *= w
+			}
+		}
+	}
+}
+
+// rescale maps heights linearly onto [-1, 1] in place and returns them. A flat map
+// is left at 0.
+func rescale(heights []float64) []float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, h := range heights {
+		min = math.Min(min, h)
+		max = math.Max(max, h)
+	}
+
+	for i, h := range heights {
+		if max > min {
+			heights[i] = 2*(h-min)/(max-min) - 1
+		} else {
+			heights[i] = 0
+		}
+	}
+
+	return heights
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package genesis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHeightmaps(t *testing.T) {
+	const width, height = 40, 25
+
+	var heightmapTests = []struct {
+		name string
+		in   Heightmap
+		diff Heightmap
+	}{
+		{"diamond-square", DiamondSquare{Seed: 1, Roughness: 0.7}, DiamondSquare{Seed: 2, Roughness: 0.7}},
+		{"fault-lines", FaultLines{Seed: 1, Faults: 50}, FaultLines{Seed: 2, Faults: 50}},
+		{"particle-deposition", ParticleDeposition{Seed: 1, Particles: 2000, Drops: 4}, ParticleDeposition{Seed: 2, Particles: 2000, Drops: 4}},
+		{"spectral", SpectralSynthesis{Seed: 1, Exponent: 2}, SpectralSynthesis{Seed: 2, Exponent: 2}},
+	}
+
+	for _, tt := range heightmapTests {
+		h := tt.in.Heights(width, height)
+
+		if len(h) != width*height {
+			t.Errorf("%s: Expected %d heights, got %d", tt.name, width*height, len(h))
+			continue
+		}
+
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, v := range h {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		if min != -1 || max != 1 {
+			t.Errorf("%s: Expected heights spanning [-1, 1], got [%v, %v]", tt.name, min, max)
+		}
+
+		if !reflect.DeepEqual(h, tt.in.Heights(width, height)) {
+			t.Errorf("%s: Expected the same seed to give the same heights", tt.name)
+		}
+		if reflect.DeepEqual(h, tt.diff.Heights(width, height)) {
+			t.Errorf("%s: Expected different seeds to give different heights", tt.name)
+		}
+	}
+}
+
+func TestSpectralExponent(t *testing.T) {
+	roughness := func(exponent float64) float64 {
+		h := SpectralSynthesis{Seed: 1, Exponent: exponent}.Heights(64, 64)
+
+		var sum float64
+		for i := 1; i < len(h); i++ {
+			sum += math.Abs(h[i] - h[i-1])
+		}
+		return sum
+	}
+
+	if smooth, rough := roughness(3), roughness(1); smooth >= rough {
+		t.Errorf("Expected a higher exponent to give smoother terrain, got %v and %v", smooth, rough)
+	}
+}
+
+func TestParticleRemainder(t *testing.T) {
+	// With fewer grains than vents, every grain is left over after dividing them up.
+	h := ParticleDeposition{Seed: 1, Particles: 3, Drops: 4}.Heights(10, 10)
+
+	max := math.Inf(-1)
+	for _, v := range h {
+		max = math.Max(max, v)
+	}
+	if max != 1 {
+		t.Errorf("Expected the leftover grains to be dropped, got a flat map")
+	}
+}
+
+func TestSpectralTiles(t *testing.T) {
+	const n = 64
+	h := SpectralSynthesis{Seed: 1, Exponent: 2}.Heights(n, n)
+
+	// Steps across the wrapped edges should look like steps anywhere else.
+	var inner, edge float64
+	for i := 0; i < n; i++ {
+		for j := 1; j < n; j++ {
+			inner = math.Max(inner, math.Abs(h[i*n+j]-h[i*n+j-1]))
+			inner = math.Max(inner, math.Abs(h[j*n+i]-h[(j-1)*n+i]))
+		}
+		edge = math.Max(edge, math.Abs(h[i*n]-h[i*n+n-1]))
+		edge = math.Max(edge, math.Abs(h[i]-h[(n-1)*n+i]))
+	}
+
+	if edge > inner {
+		t.Errorf("Expected a %dx%d map to tile, got a step of %v across the edge and at most %v inside", n, n, edge, inner)
+	}
+}
@@ -1,9 +1,15 @@
 package genesis
 
 import (
+	"fmt"
 	l "github.com/therealfakemoot/genesis/log"
 	"html/template"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
 	"io"
+	"math"
 )
 
 var topoMap = `
@@ -49,3 +55,112 @@ func RenderTopoHTML(w io.Writer) {
 
 	t.Execute(w, nil)
 }
+
+// RenderGrayscalePNG encodes a width x height grid of values, in row-major order, as a
+// grayscale PNG. Values are mapped linearly from [min, max] onto black to white and
+// clamped outside it.
+func RenderGrayscalePNG(w io.Writer, values []float64, width, height int, min, max float64) error {
+	return png.Encode(w, grayImage(values, width, height, min, max))
+}
+
+// RenderArrowsSVG writes an SVG overlay drawing f as a grid of arrows, one for every
+// stride cells in each direction. Each cell is cellSize pixels wide, so the overlay
+// lines up with a map rendered at the same scale. Arrows are scaled so the longest
+// vector in the field spans most of the gap between arrows.
+func RenderArrowsSVG(w io.Writer, f VectorField, stride int, cellSize float64) error {
+	if stride < 1 {
+		stride = 1
+	}
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]g" height="%[2]g" viewBox="0 0 %[1]g %[2]g" stroke="#000" stroke-width="1">
+<defs><marker id="head" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="4" markerHeight="4" orient="auto"><path d="M0,0 L10,5 L0,10 z" stroke="none"/></marker></defs>
+`, float64(f.Grid.X)*cellSize, float64(f.Grid.Y)*cellSize)
+	if err != nil {
+		return err
+	}
+
+	var scale float64
+	if max := f.MaxLen(); max > 0 {
+		scale = 0.9 * float64(stride) * cellSize / max
+	}
+
+	for y := stride / 2; y < f.Grid.Y; y += stride {
+		for x := stride / 2; x < f.Grid.X; x += stride {
+			v := f.At(x, y)
+			if v.Len() == 0 {
+				continue
+			}
+
+			// Centre each arrow on its cell.
+			cx, cy := (float64(x)+0.5)*cellSize, (float64(y)+0.5)*cellSize
+			dx, dy := v.X*scale/2, v.Y*scale/2
+
+			_, err = fmt.Fprintf(w, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" marker-end=\"url(#head)\"/>\n", cx-dx, cy-dy, cx+dx, cy+dy)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// RenderMapPNG encodes m as a grayscale PNG, mapping heights from [min, max] onto
+// black to white.
+func RenderMapPNG(w io.Writer, m Map, min, max float64) error {
+	return RenderGrayscalePNG(w, m.Points, m.Grid.X, m.Grid.Y, min, max)
+}
+
+// RenderGIF encodes frames as a looping animated GIF, mapping heights from [min, max]
+// onto black to white. delay is the time each frame is shown, in hundredths of a
+// second.
+func RenderGIF(w io.Writer, frames []Map, min, max float64, delay int) error {
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = color.Gray{Y: uint8(i)}
+	}
+
+	anim := &gif.GIF{}
+	for _, m := range frames {
+		gray := grayImage(m.Points, m.Grid.X, m.Grid.Y, min, max)
+
+		img := image.NewPaletted(gray.Bounds(), palette)
+		copy(img.Pix, gray.Pix)
+
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	return gif.EncodeAll(w, anim)
+}
+
+// Range returns the lowest and highest heights across maps, so a sequence of frames
+// can share one gray scale.
+func Range(maps ...Map) (min, max float64) {
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, m := range maps {
+		for _, v := range m.Points {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+	return min, max
+}
+
+// grayImage maps a row-major grid of values onto an image.Gray, which shares the
+// same layout.
+func grayImage(values []float64, width, height int, min, max float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+
+	span := max - min
+	for i, v := range values[:width*height] {
+		t := 0.5
+		if span > 0 {
+			t = math.Max(0, math.Min(1, (v-min)/span))
+		}
+		img.Pix[i] = uint8(t*255 + 0.5)
+	}
+
+	return img
+}
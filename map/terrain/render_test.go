@@ -0,0 +1,45 @@
+package genesis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderArrowsSVG(t *testing.T) {
+	f := NewVectorField(Grid{X: 8, Y: 6})
+	for i := range f.Vectors {
+		f.Vectors[i] = Vector{X: 1, Y: float64(i % 3)}
+	}
+	// Zero vectors get no arrow.
+	f.Set(1, 1, Vector{})
+
+	var arrowTests = []struct {
+		name   string
+		f      VectorField
+		stride int
+		arrows int
+	}{
+		{"field", f, 2, 11},
+		{"every cell", f, 1, 47},
+		{"still", NewVectorField(Grid{X: 8, Y: 6}), 2, 0},
+	}
+
+	for _, tt := range arrowTests {
+		var buf bytes.Buffer
+		if err := RenderArrowsSVG(&buf, tt.f, tt.stride, 10); err != nil {
+			t.Fatalf("%s: Expected rendering to succeed, got %v", tt.name, err)
+		}
+		svg := buf.String()
+
+		if !strings.Contains(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="80" height="60" viewBox="0 0 80 60"`) {
+			t.Errorf("%s: Expected an 80x60 viewBox, got %q", tt.name, strings.SplitN(svg, "\n", 2)[0])
+		}
+		if n := strings.Count(svg, "<line "); n != tt.arrows {
+			t.Errorf("%s: Expected %d arrows, got %d", tt.name, tt.arrows, n)
+		}
+		if strings.Contains(svg, "NaN") || strings.Contains(svg, "Inf") {
+			t.Errorf("%s: Expected finite coordinates, got %q", tt.name, svg)
+		}
+	}
+}
@@ -3,17 +3,9 @@ package genesis
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
-func flatten(source [][]float64) []float64 {
-	r := make([]float64, len(source)*len(source[0]))
-	for _, a := range source {
-		r = append(r, a...)
-	}
-
-	return r
-}
-
 // Grid encodes the dimensions of a Map.
 type Grid struct {
 	X int
@@ -22,34 +14,95 @@ type Grid struct {
 }
 
 // Map describes the topographical layout of a map.
+//
+// Points holds the height of every cell in one contiguous, row-major slice of
+// Grid.X*Grid.Y values; cell x,y is Points[y*Grid.X+x]. Use At, Set and Row rather
+// than indexing it by hand.
 type Map struct {
 	Grid   Grid
-	Points [][]float64
+	Points []float64
+}
+
+// NewMap returns a flat Map covering grid.
+func NewMap(grid Grid) Map {
+	return Map{
+		Grid:   grid,
+		Points: make([]float64, grid.X*grid.Y),
+	}
+}
+
+// At returns the height in column x, row y.
+func (m Map) At(x, y int) float64 {
+	return m.Points[y*m.Grid.X+x]
+}
+
+// Set stores the height in column x, row y.
+func (m Map) Set(x, y int, v float64) {
+	m.Points[y*m.Grid.X+x] = v
+}
+
+// Row returns row y. The slice shares storage with the map, so writing to it
+// changes the map.
+func (m Map) Row(y int) []float64 {
+	return m.Points[y*m.Grid.X : (y+1)*m.Grid.X]
 }
 
 // MarshalJSON is used for encoding maps to a JSON payload suitable for use with d3.js .
+// Points is already laid out the way d3-contour expects, so it is encoded in place.
 func (m Map) MarshalJSON() ([]byte, error) {
 
 	mj := MapJSON{}
 	mj.Width = m.Grid.X
 	mj.Height = m.Grid.Y
-	mj.Values = flatten(m.Points)
+	mj.Values = m.Points
 
 	return json.Marshal(mj)
 }
 
+// UnmarshalJSON decodes a payload produced by MarshalJSON.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	mj := MapJSON{}
+	if err := json.Unmarshal(data, &mj); err != nil {
+		return err
+	}
+
+	if len(mj.Values) != mj.Width*mj.Height {
+		return fmt.Errorf("map is %dx%d but has %d values", mj.Width, mj.Height, len(mj.Values))
+	}
+
+	m.Grid = Grid{X: mj.Width, Y: mj.Height}
+	m.Points = mj.Values
+
+	return nil
+}
+
 func (m Map) String() string {
 
-	s := ""
+	var s strings.Builder
 
 	grid, _ := json.Marshal(m.Grid)
-	s += string(grid)
-	s += "\n"
-	for _, r := range m.Points {
-		s += fmt.Sprintf("%v", r) + "\n"
+	s.Write(grid)
+	s.WriteString("\n")
+	for y := 0; y < m.Grid.Y; y++ {
+		fmt.Fprintf(&s, "%v\n", m.Row(y))
 	}
 
-	return s
+	return s.String()
+}
+
+// Density returns a function reporting the height of the cell containing x,y, mapped
+// linearly from [min, max] onto [0, 1]. It is meant to drive the spacing of a
+// PoissonSampler covering the map, so features can crowd together in the lowlands
+// or the highlands. Points outside the map report 0.
+func (m Map) Density(min, max float64) func(x, y float64) float64 {
+	return func(x, y float64) float64 {
+		i, j := int(x), int(y)
+		if x < 0 || y < 0 || i >= m.Grid.X || j >= m.Grid.Y || max == min {
+			return 0
+		}
+
+		return (m.At(i, j) - min) / (max - min)
+	}
 }
 
 // RenderHTML creates an HTML file that displays a contour map of the terrain data.
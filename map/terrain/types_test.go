@@ -0,0 +1,53 @@
+package genesis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMapIndexing(t *testing.T) {
+	m := NewMap(Grid{X: 3, Y: 2})
+
+	m.Set(2, 1, 5)
+	m.Row(0)[1] = 7
+
+	if m.At(2, 1) != 5 || m.At(1, 0) != 7 {
+		t.Errorf("Expected 5 and 7, got %v and %v", m.At(2, 1), m.At(1, 0))
+	}
+
+	want := []float64{0, 7, 0, 0, 0, 5}
+	if !reflect.DeepEqual(m.Points, want) {
+		t.Errorf("Expected row-major points %v, got %v", want, m.Points)
+	}
+}
+
+func TestMapJSON(t *testing.T) {
+	m := NewMap(Grid{X: 4, Y: 3})
+	for i := range m.Points {
+		m.Points[i] = float64(i)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Expected encoding to succeed, got %v", err)
+	}
+
+	var mj MapJSON
+	json.Unmarshal(b, &mj)
+	if mj.Width != 4 || mj.Height != 3 || len(mj.Values) != 12 {
+		t.Errorf("Expected a 4x3 payload with 12 values, got %dx%d with %d", mj.Width, mj.Height, len(mj.Values))
+	}
+
+	var decoded Map
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Expected decoding to succeed, got %v", err)
+	}
+	if decoded.Grid.X != 4 || decoded.Grid.Y != 3 || !reflect.DeepEqual(decoded.Points, m.Points) {
+		t.Errorf("Expected %v, got %v", m, decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"width":2,"height":2,"values":[1,2,3]}`), &decoded); err == nil {
+		t.Errorf("Expected an error for a short payload, got nil")
+	}
+}
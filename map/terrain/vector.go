@@ -0,0 +1,147 @@
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	noise "github.com/therealfakemoot/genesis/noise"
+)
+
+// Vector is a 2D vector in map space. X points along increasing columns and Y along
+// increasing rows.
+type Vector struct {
+	X float64
+	Y float64
+}
+
+// Len returns the length of v.
+func (v Vector) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// VectorField is a layer holding one Vector per cell of a Grid, such as a wind or
+// ocean current map. Vectors are stored in row-major order.
+type VectorField struct {
+	Grid    Grid
+	Vectors []Vector
+}
+
+// NewVectorField returns a zeroed VectorField covering grid.
+func NewVectorField(grid Grid) VectorField {
+	return VectorField{
+		Grid:    grid,
+		Vectors: make([]Vector, grid.X*grid.Y),
+	}
+}
+
+// At returns the vector in column x, row y.
+func (f VectorField) At(x, y int) Vector {
+	return f.Vectors[y*f.Grid.X+x]
+}
+
+// Set stores the vector in column x, row y.
+func (f VectorField) Set(x, y int, v Vector) {
+	f.Vectors[y*f.Grid.X+x] = v
+}
+
+// MaxLen returns the length of the longest vector in the field.
+func (f VectorField) MaxLen() float64 {
+	var max float64
+	for _, v := range f.Vectors {
+		max = math.Max(max, v.Len())
+	}
+	return max
+}
+
+// VectorFieldJSON is the JSON form of a VectorField. The components are stored as two
+// flat, row-major arrays, the same layout MapJSON uses for heights.
+type VectorFieldJSON struct {
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	U      []float64 `json:"u"`
+	V      []float64 `json:"v"`
+}
+
+// MarshalJSON encodes the field as a VectorFieldJSON.
+func (f VectorField) MarshalJSON() ([]byte, error) {
+	fj := VectorFieldJSON{
+		Width:  f.Grid.X,
+		Height: f.Grid.Y,
+		U:      make([]float64, len(f.Vectors)),
+		V:      make([]float64, len(f.Vectors)),
+	}
+
+	for i, v := range f.Vectors {
+		fj.U[i] = v.X
+		fj.V[i] = v.Y
+	}
+
+	return json.Marshal(fj)
+}
+
+// UnmarshalJSON decodes a field encoded by MarshalJSON.
+func (f *VectorField) UnmarshalJSON(data []byte) error {
+	var fj VectorFieldJSON
+	if err := json.Unmarshal(data, &fj); err != nil {
+		return err
+	}
+
+	n := fj.Width * fj.Height
+	if len(fj.U) != n || len(fj.V) != n {
+		return fmt.Errorf("vector field is %dx%d but has %d u and %d v components", fj.Width, fj.Height, len(fj.U), len(fj.V))
+	}
+
+	*f = NewVectorField(Grid{X: fj.Width, Y: fj.Height})
+	for i := range f.Vectors {
+		f.Vectors[i] = Vector{X: fj.U[i], Y: fj.V[i]}
+	}
+
+	return nil
+}
+
+// GenerateCurl produces a divergence-free VectorField by taking the curl of mg.Noise,
+// laid out the same way Generate lays out a height map with the same arguments.
+//
+// With the Sphere projection the curl is taken on the surface of the planet, so the
+// flow wraps around the left and right edges and passes over the poles. X is then
+// the eastward component and Y the southward one, the direction rows run in, so the
+// field lines up with the map just as it does on a flat projection.
+func (mg *MapGen) GenerateCurl(x, y, sampleScale float64) VectorField {
+	f := NewVectorField(Grid{X: int(x), Y: int(y)})
+	c := noise.NewCurl(mg.Noise)
+
+	if mg.Projection == Sphere {
+		radius := x * sampleScale / (2 * math.Pi)
+
+		for j := 0; j < f.Grid.Y; j++ {
+			lat := math.Pi/2 - math.Pi*(float64(j)+0.5)/y
+			sinLat, cosLat := math.Sincos(lat)
+
+			for i := 0; i < f.Grid.X; i++ {
+				sinLon, cosLon := math.Sincos(2 * math.Pi * float64(i) / x)
+
+				gx, gy, gz := c.Gradient3(radius*cosLat*cosLon, radius*cosLat*sinLon, radius*sinLat)
+
+				// Project the gradient onto the local east and north directions. Rows
+				// run south, so in the map's own column and row directions the
+				// gradient is ( east, -north ), which is rotated a quarter turn the
+				// same way Curl rotates it on a flat map.
+				east := -sinLon*gx + cosLon*gy
+				north := -sinLat*cosLon*gx - sinLat*sinLon*gy + cosLat*gz
+				f.Set(i, j, Vector{X: -north, Y: -east})
+			}
+		}
+
+		return f
+	}
+
+	for j := 0; j < f.Grid.Y; j++ {
+		for i := 0; i < f.Grid.X; i++ {
+			vx, vy := c.Eval3(float64(i)*sampleScale, float64(j)*sampleScale, 0)
+			f.Set(i, j, Vector{X: vx, Y: vy})
+		}
+	}
+
+	return f
+}
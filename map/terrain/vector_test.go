@@ -0,0 +1,31 @@
+package genesis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestVectorFieldJSON(t *testing.T) {
+	f := NewVectorField(Grid{X: 3, Y: 2})
+	for i := range f.Vectors {
+		f.Vectors[i] = Vector{X: float64(i), Y: -float64(i) / 2}
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("Expected encoding to succeed, got %v", err)
+	}
+
+	var got VectorField
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Expected decoding to succeed, got %v", err)
+	}
+	if !reflect.DeepEqual(f, got) {
+		t.Errorf("Expected %v after a round trip, got %v", f, got)
+	}
+
+	if err = json.Unmarshal([]byte(`{"width":2,"height":2,"u":[1,2,3],"v":[1,2,3,4]}`), &got); err == nil {
+		t.Errorf("Expected an error for a payload with too few components, got nil")
+	}
+}
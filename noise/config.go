@@ -0,0 +1,136 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+)
+
+// Normalization selects how a Noise instance maps its output range.
+type Normalization string
+
+const (
+	// NormalizeRaw leaves the output as computed. With the default constants it falls
+	// within roughly [-1, 1], but other lattice constants can push it outside.
+	NormalizeRaw Normalization = "raw"
+	// NormalizeClamp guarantees the output lies within [-1, 1].
+	NormalizeClamp Normalization = "clamp"
+	// NormalizeUnit rescales the output to [0, 1], clamping anything that falls outside.
+	NormalizeUnit Normalization = "unit"
+)
+
+// ParseNormalization returns the Normalization with the given name.
+func ParseNormalization(name string) (Normalization, error) {
+	switch n := Normalization(name); n {
+	case NormalizeRaw, NormalizeClamp, NormalizeUnit:
+		return n, nil
+	case "":
+		return NormalizeRaw, nil
+	}
+
+	return "", fmt.Errorf("unknown normalization %q", name)
+}
+
+// LatticeMode selects how a Noise instance picks the gradient at each lattice vertex.
+type LatticeMode string
+
+const (
+	// LatticeTable looks gradients up in the 256-entry permutation table, as the
+	// reference implementation does. The noise repeats every 256 units along each
+	// axis, which shows as tiling on large maps. It is the default, so existing maps
+	// can be reproduced.
+	LatticeTable LatticeMode = "table"
+	// LatticeHash hashes the vertex coordinates together with a key derived from the
+	// permutation, so the noise only repeats when the 32-bit lattice coordinates wrap.
+	LatticeHash LatticeMode = "hash"
+)
+
+// ParseLatticeMode returns the LatticeMode with the given name.
+func ParseLatticeMode(name string) (LatticeMode, error) {
+	switch m := LatticeMode(name); m {
+	case LatticeTable, LatticeHash:
+		return m, nil
+	case "":
+		return LatticeTable, nil
+	}
+
+	return "", fmt.Errorf("unknown lattice mode %q", name)
+}
+
+// Config holds the per-instance constants that shape OpenSimplex output.
+//
+// Stretch and Norm replace the 3D lattice constants, so they affect Eval3 (and
+// everything built on it, like MapGen) while Eval2 and Eval4 keep Kurt Spencer's
+// values. Stretch skews input coordinates onto the lattice and must lie within
+// (-1/3, -1/6]; moving it below the default reshapes the lattice cells, stretching
+// features along the main diagonal. A weaker skew than the default makes the cells
+// larger than the reach of each vertex, which breaks the surface up.
+//
+// Squish is the inverse skew and is not free: any other value leaves seams and
+// cliffs between neighbouring simplices, so NewWithConfig always derives it from
+// Stretch with SquishFor. Norm divides the summed contributions; lowering it raises
+// the contrast. Normalization and Lattice apply to every Eval.
+type Config struct {
+	Stretch       float64       `json:"stretch"`
+	Squish        float64       `json:"squish"`
+	Norm          float64       `json:"norm"`
+	Normalization Normalization `json:"normalization"`
+	Lattice       LatticeMode   `json:"lattice"`
+}
+
+// DefaultConfig returns the constants and permutation table lattice from the
+// reference implementation, with the output left unnormalized.
+func DefaultConfig() Config {
+	return Config{
+		Stretch:       stretchConstant3D,
+		Squish:        squishConstant3D,
+		Norm:          normConstant3D,
+		Normalization: NormalizeRaw,
+		Lattice:       LatticeTable,
+	}
+}
+
+// SquishFor returns the squish constant that undoes a 3D stretch, so that lattice
+// points skewed by one land back where the other expects them.
+func SquishFor(stretch float64) float64 {
+	return -stretch / (1 + 3*stretch)
+}
+
+// Validate reports whether c describes a usable lattice: Stretch within (-1/3, -1/6],
+// Squish its inverse, a non-zero Norm and known Normalization and Lattice names.
+func (c Config) Validate() error {
+	if !(c.Stretch > -1.0/3 && c.Stretch <= stretchConstant3D) {
+		return fmt.Errorf("stretch constant %v is outside (-1/3, -1/6]", c.Stretch)
+	}
+	if q := SquishFor(c.Stretch); math.Abs(c.Squish-q) > 1e-12*math.Abs(q) {
+		return fmt.Errorf("squish constant %v does not match stretch %v; want %v", c.Squish, c.Stretch, q)
+	}
+	if c.Norm == 0 {
+		return fmt.Errorf("zero norm constant")
+	}
+	if _, err := ParseNormalization(string(c.Normalization)); err != nil {
+		return err
+	}
+	if _, err := ParseLatticeMode(string(c.Lattice)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalize applies the instance's Normalization to v, also returning the slope of the
+// mapping at v so derivatives can be scaled to match.
+func (s *Noise) normalize(v float64) (float64, float64) {
+	switch s.config.Normalization {
+	case NormalizeClamp:
+		if v < -1 || v > 1 {
+			return math.Max(-1, math.Min(1, v)), 0
+		}
+	case NormalizeUnit:
+		if v < -1 || v > 1 {
+			return math.Max(0, math.Min(1, v*0.5+0.5)), 0
+		}
+		return v*0.5 + 0.5, 0.5
+	}
+
+	return v, 1
+}
@@ -0,0 +1,47 @@
+package genesis
+
+import (
+	"math"
+	"testing"
+)
+
+// finiteOnly hides the analytic derivatives of a Source.
+type finiteOnly struct {
+	Source
+}
+
+func TestCurlDivergenceFree(t *testing.T) {
+	const h = 1e-3
+
+	// Perlin noise is smooth to the second derivative, so the divergence is zero up
+	// to the error of the finite differences.
+	c := NewCurl(NewPerlinWithSeed(7))
+
+	for i := 0; i < 200; i++ {
+		x, y := float64(i)*0.377-20, float64(i)*-0.291+11
+
+		vxr, _ := c.Eval3(x+h, y, 0.5)
+		vxl, _ := c.Eval3(x-h, y, 0.5)
+		_, vyu := c.Eval3(x, y+h, 0.5)
+		_, vyd := c.Eval3(x, y-h, 0.5)
+
+		if div := (vxr-vxl)/(2*h) + (vyu-vyd)/(2*h); math.Abs(div) > 1e-3 {
+			t.Fatalf("Expected zero divergence at (%v, %v), got %v", x, y, div)
+		}
+	}
+}
+
+func TestCurlAnalyticMatchesFinite(t *testing.T) {
+	n := NewWithSeed(7)
+	analytic, finite := NewCurl(n), NewCurl(finiteOnly{n})
+
+	for i := 0; i < 200; i++ {
+		x, y := float64(i)*0.377-20, float64(i)*-0.291+11
+
+		ax, ay := analytic.Eval2(x, y)
+		fx, fy := finite.Eval2(x, y)
+		if math.Abs(ax-fx) > 1e-4 || math.Abs(ay-fy) > 1e-4 {
+			t.Fatalf("Expected (%v, %v) at (%v, %v), got (%v, %v)", fx, fy, x, y, ax, ay)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+)
+
+// FractalMode selects how a Fractal combines its octaves.
+type FractalMode string
+
+const (
+	// FBM is plain fractional Brownian motion: a weighted sum of octaves.
+	FBM FractalMode = "fbm"
+	// Billow folds every octave with an absolute value, giving puffy, rounded
+	// hills separated by sharp creases.
+	Billow FractalMode = "billow"
+	// Ridged is Musgrave's ridged multifractal. Each octave is inverted around
+	// Offset and squared, and is weighted by the octave before it scaled by Gain,
+	// which produces sharp, branching mountain ridges.
+	Ridged FractalMode = "ridged"
+	// Hybrid is Musgrave's hybrid multifractal. Octaves are shifted by Offset
+	// and weighted by the running result, so valleys stay smooth while peaks
+	// grow rough.
+	Hybrid FractalMode = "hybrid"
+)
+
+// ParseFractalMode returns the FractalMode with the given name.
+func ParseFractalMode(name string) (FractalMode, error) {
+	switch m := FractalMode(name); m {
+	case FBM, Billow, Ridged, Hybrid:
+		return m, nil
+	case "":
+		return FBM, nil
+	}
+
+	return "", fmt.Errorf("unknown fractal mode %q", name)
+}
+
+// Fractal sums several octaves of a Source into fractional Brownian motion (fBm)
+// or one of the multifractal variants selected by Mode.
+//
+// Each octave is sampled at Lacunarity times the frequency of the one before it
+// and weighted by Persistence times its amplitude. The sum is divided by the total
+// amplitude, so the output stays within the range of the wrapped Source
+// ( [-1, 1] for OpenSimplex ) no matter how many octaves are used. The Ridged and
+// Hybrid modes are rescaled to roughly the same range.
+type Fractal struct {
+	Source      Source
+	Mode        FractalMode
+	Octaves     int
+	Lacunarity  float64
+	Persistence float64
+	Frequency   float64
+
+	// Offset is only used by the Ridged and Hybrid modes, and Gain only by Ridged.
+	Offset float64
+	Gain   float64
+}
+
+// NewFractal wraps src using the customary defaults: six octaves, each twice the
+// frequency and half the amplitude of the last, starting at a frequency of 1.
+func NewFractal(src Source) *Fractal {
+	return &Fractal{
+		Source:      src,
+		Mode:        FBM,
+		Octaves:     6,
+		Lacunarity:  2,
+		Persistence: 0.5,
+		Frequency:   1,
+		Offset:      1,
+		Gain:        2,
+	}
+}
+
+// octaveShift is how far each octave is moved from the one before it along each
+// axis. Without it every octave would sample the Source at the origin when x, y, z
+// and w are zero, where OpenSimplex is always zero, so the octaves would line up
+// there instead of averaging out.
+var octaveShift = [4]float64{0.5347, 0.7183, 0.3066, 0.8975}
+
+// Eval2 returns the fractal value at x,y.
+func (f *Fractal) Eval2(x, y float64) float64 {
+	return f.sum(func(o, freq float64) float64 {
+		return f.Source.Eval2(x*freq+o*octaveShift[0], y*freq+o*octaveShift[1])
+	})
+}
+
+// Eval3 returns the fractal value at x,y,z.
+func (f *Fractal) Eval3(x, y, z float64) float64 {
+	return f.sum(func(o, freq float64) float64 {
+		return f.Source.Eval3(x*freq+o*octaveShift[0], y*freq+o*octaveShift[1], z*freq+o*octaveShift[2])
+	})
+}
+
+// Eval4 returns the fractal value at x,y,z,w. Sources without a fourth dimension are
+// evaluated with Eval3, ignoring w; Is4D reports which applies.
+func (f *Fractal) Eval4(x, y, z, w float64) float64 {
+	return f.sum(func(o, freq float64) float64 {
+		return eval4(f.Source, x*freq+o*octaveShift[0], y*freq+o*octaveShift[1], z*freq+o*octaveShift[2], w*freq+o*octaveShift[3])
+	})
+}
+
+// Is4D reports whether Source has a fourth dimension.
+func (f *Fractal) Is4D() bool { return Is4D(f.Source) }
+
+// sum combines Octaves samples of octave, which evaluates the wrapped Source for
+// octave number o at the given frequency.
+func (f *Fractal) sum(octave func(o, freq float64) float64) float64 {
+	var sum, norm float64
+	freq, amp := f.Frequency, 1.0
+	weight := 1.0
+
+	for o := 0; o < f.Octaves; o++ {
+		n := octave(float64(o), freq)
+
+		switch f.Mode {
+		case Billow:
+			sum += amp * (2*math.Abs(n) - 1)
+			norm += amp
+		case Ridged:
+			signal := f.Offset - math.Abs(n)
+			signal *= signal * weight
+			weight = math.Max(0, math.Min(1, signal*f.Gain))
+			sum += amp * signal
+			norm += amp * f.Offset * f.Offset
+		case Hybrid:
+			signal := (n + f.Offset) * amp
+			if o == 0 {
+				sum = signal
+				weight = signal
+			} else {
+				weight = math.Min(1, weight)
+				sum += weight * signal
+				weight *= signal
+			}
+			norm += (1 + f.Offset) * amp
+		default:
+			sum += amp * n
+			norm += amp
+		}
+
+		freq *= f.Lacunarity
+		amp *= f.Persistence
+	}
+
+	if norm == 0 {
+		return 0
+	}
+
+	switch f.Mode {
+	case Ridged, Hybrid:
+		// Both accumulate signals in roughly [0, norm]; centre them on zero.
+		return 2*sum/norm - 1
+	}
+
+	return sum / norm
+}
@@ -0,0 +1,21 @@
+package genesis
+
+import (
+	"testing"
+)
+
+func TestFractalOctaveShift(t *testing.T) {
+	src := NewWithSeed(3)
+
+	one := NewFractal(src)
+	one.Octaves = 1
+	if got, want := one.Eval3(0.4, 0.2, 0.9), src.Eval3(0.4, 0.2, 0.9); got != want {
+		t.Errorf("Expected a single octave to be the Source, got %v and %v", got, want)
+	}
+
+	// OpenSimplex is zero at the origin, so unshifted octaves would all be zero there.
+	f := NewFractal(src)
+	if f.Eval2(0, 0) == 0 || f.Eval3(0, 0, 0) == 0 || f.Eval4(0, 0, 0, 0) == 0 {
+		t.Errorf("Expected the octaves not to line up at the origin, got %v, %v and %v", f.Eval2(0, 0), f.Eval3(0, 0, 0), f.Eval4(0, 0, 0, 0))
+	}
+}
@@ -0,0 +1,54 @@
+package genesis
+
+import (
+	"math"
+	"testing"
+)
+
+// variation returns the mean change in src over a short step along each axis.
+func variation(src Source) (alongX, alongY float64) {
+	const step = 0.1
+
+	for i := 0; i < 2000; i++ {
+		x, y := float64(i)*0.731, float64(i)*-0.377
+		v := src.Eval3(x, y, 0)
+		alongX += math.Abs(src.Eval3(x+step, y, 0) - v)
+		alongY += math.Abs(src.Eval3(x, y+step, 0) - v)
+	}
+
+	return alongX, alongY
+}
+
+func TestGaborOrientation(t *testing.T) {
+	g := NewGaborWithSeed(3)
+
+	// Stripes with orientation 0 run across x, so the noise changes along x and
+	// stays nearly constant along y.
+	if x, y := variation(g); x < 3*y {
+		t.Errorf("Expected much more variation along x than y, got %v and %v", x, y)
+	}
+
+	// A steering field of 1 turns the stripes a quarter turn.
+	g.OrientationField = Const(1)
+	if x, y := variation(g); y < 3*x {
+		t.Errorf("Expected much more variation along y than x, got %v and %v", x, y)
+	}
+}
+
+func TestGaborRange(t *testing.T) {
+	g := NewGaborWithSeed(3)
+
+	var sq float64
+	for i := 0; i < 2000; i++ {
+		x, y := float64(i)*0.731, float64(i)*-0.377
+		v2, v3 := g.Eval2(x, y), g.Eval3(x, y, 0.5)
+		if math.Abs(v2) > 1.5 || math.Abs(v3) > 1.5 {
+			t.Fatalf("Expected values within roughly [-1, 1], got %v and %v", v2, v3)
+		}
+		sq += v2 * v2
+	}
+
+	if rms := math.Sqrt(sq / 2000); rms < 0.1 || rms > 0.4 {
+		t.Errorf("Expected an RMS value near 0.2, got %v", rms)
+	}
+}
@@ -0,0 +1,373 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GraphSpec describes a graph of noise modules, typically loaded from the Terrain.Graph
+// section of the configuration file:
+//
+//	terrain:
+//	  graph:
+//	    root: land
+//	    modules:
+//	      hills:
+//	        type: fractal
+//	        source: base
+//	        octaves: 4
+//	      base:
+//	        type: source
+//	        algorithm: opensimplex
+//	      peaks:
+//	        type: fractal
+//	        mode: ridged
+//	        source: base
+//	        frequency: 2
+//	      mask:
+//	        type: source
+//	        algorithm: perlin
+//	        seed: 1
+//	        frequency: 0.25
+//	      land:
+//	        type: select
+//	        sources: [hills, peaks]
+//	        control: mask
+//	        lower: 0.2
+//	        upper: 10
+//	        falloff: 0.1
+//
+// Module names are case-insensitive, because the configuration loader folds them to
+// lower case.
+type GraphSpec struct {
+	Root    string
+	Modules map[string]ModuleSpec
+}
+
+// ModuleSpec describes one module of a GraphSpec. Type selects the module and decides
+// which of the other fields it reads:
+//
+//	source      Algorithm, Seed, Frequency
+//	const       Value
+//	fractal     Source, Mode, Octaves, Lacunarity, Persistence, Frequency, Offset, Gain
+//	warp        Source, Control ( the displacement field, optional ), Strength, Depth
+//	add         Sources
+//	multiply    Sources
+//	min         Sources
+//	max         Sources
+//	blend       Sources ( exactly two ), Control
+//	select      Sources ( exactly two ), Control, Lower, Upper, Falloff
+//	scalebias   Source, Scale, Bias
+//	clamp       Source, Min, Max
+//	curve       Source, Points ( flattened input/output pairs )
+//	terrace     Source, Points, Invert
+//	invert      Source
+//	turbulence  Source, Seed, Frequency, Power, Roughness
+//	gabor       Seed, Frequency, Orientation ( degrees ), Bandwidth, Impulses,
+//	            OrientationField, FrequencyField ( both optional )
+//
+// Seed is added to the seed the graph is built with, so sibling generators can be
+// decorrelated without hard-coding absolute seeds. Settings left out fall back to the
+// same defaults as the package constructors; for scalebias that is a Scale of 1, and
+// for clamp a Min and Max of -1 and 1. An explicit zero is kept, so min: 0 clamps at
+// zero. The counts Octaves, Depth, Impulses and Roughness are the exception: none is
+// useful at zero, so zero means the default there too.
+type ModuleSpec struct {
+	Type    string
+	Source  string
+	Sources []string
+	Control string
+
+	Algorithm string
+	Seed      int64
+	Value     float64
+
+	Mode        string
+	Octaves     int
+	Lacunarity  *float64
+	Persistence *float64
+	Frequency   *float64
+	Offset      *float64
+	Gain        *float64
+
+	Strength  float64
+	Depth     int
+	Power     *float64
+	Roughness int
+
+	Scale *float64
+	Bias  float64
+	Min   *float64
+	Max   *float64
+
+	Lower   float64
+	Upper   float64
+	Falloff float64
+
+	Points []float64
+	Invert bool
+
+	Orientation      float64
+	Bandwidth        *float64
+	Impulses         int
+	OrientationField string
+	FrequencyField   string
+}
+
+// Build compiles the graph into a single Source that evaluates its root module. Each
+// module is built once, however many modules use it as an input.
+func (g GraphSpec) Build(seed int64) (Source, error) {
+	b := graphBuilder{
+		spec:     g,
+		seed:     seed,
+		built:    make(map[string]Source),
+		visiting: make(map[string]bool),
+	}
+
+	if g.Root == "" {
+		return nil, fmt.Errorf("noise graph has no root module")
+	}
+
+	return b.module(g.Root)
+}
+
+type graphBuilder struct {
+	spec     GraphSpec
+	seed     int64
+	built    map[string]Source
+	visiting map[string]bool
+}
+
+func (b *graphBuilder) module(name string) (Source, error) {
+	key := strings.ToLower(name)
+
+	if s, ok := b.built[key]; ok {
+		return s, nil
+	}
+	if b.visiting[key] {
+		return nil, fmt.Errorf("noise graph module %q depends on itself", name)
+	}
+
+	m, ok := b.spec.Modules[key]
+	if !ok {
+		return nil, fmt.Errorf("noise graph has no module named %q", name)
+	}
+
+	b.visiting[key] = true
+	s, err := b.build(m)
+	b.visiting[key] = false
+
+	if err != nil {
+		return nil, fmt.Errorf("module %q: %v", name, err)
+	}
+
+	b.built[key] = s
+	return s, nil
+}
+
+func (b *graphBuilder) modules(names []string) ([]Source, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no sources given")
+	}
+
+	sources := make([]Source, len(names))
+	for i, n := range names {
+		s, err := b.module(n)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = s
+	}
+
+	return sources, nil
+}
+
+func (b *graphBuilder) pair(m ModuleSpec) (Source, Source, Source, error) {
+	if len(m.Sources) != 2 {
+		return nil, nil, nil, fmt.Errorf("%s needs exactly two sources, got %d", m.Type, len(m.Sources))
+	}
+
+	ab, err := b.modules(m.Sources)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c, err := b.module(m.Control)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ab[0], ab[1], c, nil
+}
+
+func (b *graphBuilder) build(m ModuleSpec) (Source, error) {
+	switch strings.ToLower(m.Type) {
+	case "source":
+		s, err := NewSource(m.Algorithm, b.seed+m.Seed)
+		if err != nil {
+			return nil, err
+		}
+		if f := m.Frequency; f != nil && *f != 1 {
+			s = &ScaleInput{Source: s, X: *f, Y: *f, Z: *f, W: *f}
+		}
+		return s, nil
+
+	case "const":
+		return Const(m.Value), nil
+
+	case "gabor":
+		g := NewGaborWithSeed(b.seed + m.Seed)
+		g.Orientation = m.Orientation * math.Pi / 180
+		setFloat(&g.Frequency, m.Frequency)
+		setFloat(&g.Bandwidth, m.Bandwidth)
+		setInt(&g.Impulses, m.Impulses)
+
+		var err error
+		if m.OrientationField != "" {
+			if g.OrientationField, err = b.module(m.OrientationField); err != nil {
+				return nil, err
+			}
+		}
+		if m.FrequencyField != "" {
+			if g.FrequencyField, err = b.module(m.FrequencyField); err != nil {
+				return nil, err
+			}
+		}
+		return g, nil
+
+	case "fractal":
+		src, err := b.module(m.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		f := NewFractal(src)
+		if f.Mode, err = ParseFractalMode(m.Mode); err != nil {
+			return nil, err
+		}
+		setInt(&f.Octaves, m.Octaves)
+		setFloat(&f.Lacunarity, m.Lacunarity)
+		setFloat(&f.Persistence, m.Persistence)
+		setFloat(&f.Frequency, m.Frequency)
+		setFloat(&f.Offset, m.Offset)
+		setFloat(&f.Gain, m.Gain)
+		return f, nil
+
+	case "warp":
+		src, err := b.module(m.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		w := NewWarp(src, m.Strength)
+		setInt(&w.Depth, m.Depth)
+		if m.Control != "" {
+			if w.Field, err = b.module(m.Control); err != nil {
+				return nil, err
+			}
+		}
+		return w, nil
+
+	case "add", "multiply", "min", "max":
+		sources, err := b.modules(m.Sources)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(m.Type) {
+		case "add":
+			return Add(sources), nil
+		case "multiply":
+			return Multiply(sources), nil
+		case "min":
+			return Min(sources), nil
+		}
+		return Max(sources), nil
+
+	case "blend":
+		first, second, control, err := b.pair(m)
+		if err != nil {
+			return nil, err
+		}
+		return &Blend{A: first, B: second, Control: control}, nil
+
+	case "select":
+		first, second, control, err := b.pair(m)
+		if err != nil {
+			return nil, err
+		}
+		return &Select{A: first, B: second, Control: control, Lower: m.Lower, Upper: m.Upper, Falloff: m.Falloff}, nil
+	}
+
+	switch strings.ToLower(m.Type) {
+	case "scalebias", "clamp", "curve", "terrace", "invert", "turbulence":
+	default:
+		return nil, fmt.Errorf("unknown module type %q", m.Type)
+	}
+
+	// Everything else modifies a single source.
+	src, err := b.module(m.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(m.Type) {
+	case "scalebias":
+		sb := &ScaleBias{Source: src, Scale: 1, Bias: m.Bias}
+		setFloat(&sb.Scale, m.Scale)
+		return sb, nil
+
+	case "clamp":
+		c := &Clamp{Source: src, Min: -1, Max: 1}
+		setFloat(&c.Min, m.Min)
+		setFloat(&c.Max, m.Max)
+		return c, nil
+
+	case "curve":
+		if len(m.Points)%2 != 0 {
+			return nil, fmt.Errorf("curve points must be input/output pairs, got %d values", len(m.Points))
+		}
+
+		points := make([]ControlPoint, len(m.Points)/2)
+		for i := range points {
+			points[i] = ControlPoint{In: m.Points[2*i], Out: m.Points[2*i+1]}
+		}
+		return NewCurve(src, points), nil
+
+	case "terrace":
+		for i := 1; i < len(m.Points); i++ {
+			if m.Points[i] <= m.Points[i-1] {
+				return nil, fmt.Errorf("terrace points must be increasing")
+			}
+		}
+		return &Terrace{Source: src, Points: m.Points, Invert: m.Invert}, nil
+
+	case "invert":
+		return &Invert{Source: src}, nil
+
+	case "turbulence":
+		frequency, power, roughness := 1.0, 1.0, 3
+		setFloat(&frequency, m.Frequency)
+		setFloat(&power, m.Power)
+		setInt(&roughness, m.Roughness)
+		return NewTurbulence(src, b.seed+m.Seed, frequency, power, roughness), nil
+	}
+
+	return nil, fmt.Errorf("unknown module type %q", m.Type)
+}
+
+// setFloat and setInt overwrite a default only when the spec gave a value. Fields
+// where zero is a meaningful setting, such as a clamp bound, are pointers so that an
+// explicit zero can be told apart from a missing key.
+func setFloat(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setInt(dst *int, v int) {
+	if v != 0 {
+		*dst = v
+	}
+}
@@ -0,0 +1,259 @@
+package genesis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// graphExample is the configuration documented on GraphSpec.
+const graphExample = `
+terrain:
+  graph:
+    root: land
+    modules:
+      hills:
+        type: fractal
+        source: base
+        octaves: 4
+      base:
+        type: source
+        algorithm: opensimplex
+      peaks:
+        type: fractal
+        mode: ridged
+        source: base
+        frequency: 2
+      mask:
+        type: source
+        algorithm: perlin
+        seed: 1
+        frequency: 0.25
+      land:
+        type: select
+        sources: [hills, peaks]
+        control: mask
+        lower: 0.2
+        upper: 10
+        falloff: 0.1
+`
+
+func loadGraph(t *testing.T, config string) GraphSpec {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(config)); err != nil {
+		t.Fatalf("Expected the configuration to parse, got %v", err)
+	}
+
+	var spec GraphSpec
+	if err := v.UnmarshalKey("terrain.graph", &spec); err != nil {
+		t.Fatalf("Expected the graph to decode, got %v", err)
+	}
+
+	return spec
+}
+
+func TestGraphExample(t *testing.T) {
+	spec := loadGraph(t, graphExample)
+
+	src, err := spec.Build(1)
+	if err != nil {
+		t.Fatalf("Expected the documented graph to build, got %v", err)
+	}
+
+	again, _ := spec.Build(1)
+	for i := 0; i < 100; i++ {
+		x, y := float64(i)*0.37, float64(i)*0.11
+
+		v := src.Eval2(x, y)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatalf("Expected a finite value at (%v, %v), got %v", x, y, v)
+		}
+		if w := again.Eval2(x, y); v != w {
+			t.Fatalf("Expected the same seed to give the same graph, got %v and %v", v, w)
+		}
+	}
+}
+
+func TestGraphErrors(t *testing.T) {
+	var graphErrorTests = []struct {
+		name string
+		spec GraphSpec
+		want string
+	}{
+		{"no root", GraphSpec{}, "no root"},
+		{"unknown module", GraphSpec{Root: "a", Modules: map[string]ModuleSpec{
+			"a": {Type: "invert", Source: "missing"},
+		}}, `no module named "missing"`},
+		{"cycle", GraphSpec{Root: "a", Modules: map[string]ModuleSpec{
+			"a": {Type: "invert", Source: "b"},
+			"b": {Type: "scalebias", Source: "a"},
+		}}, "depends on itself"},
+		{"unknown type", GraphSpec{Root: "a", Modules: map[string]ModuleSpec{
+			"a": {Type: "wobble"},
+		}}, `unknown module type "wobble"`},
+	}
+
+	for _, tt := range graphErrorTests {
+		_, err := tt.spec.Build(1)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestGraphModules(t *testing.T) {
+	spec := loadGraph(t, `
+terrain:
+  graph:
+    modules:
+      low:
+        type: const
+        value: -1
+      high:
+        type: const
+        value: 1
+      half:
+        type: const
+        value: 0.5
+      shifted:
+        type: scalebias
+        source: half
+        bias: 0.25
+      floor:
+        type: clamp
+        source: low
+        min: -0.5
+      ceiling:
+        type: clamp
+        source: shifted
+        min: -0.5
+      curve:
+        type: curve
+        source: half
+        points: [-1, -1, 0, 0.5, 1, 1]
+      terrace:
+        type: terrace
+        source: half
+        points: [-1, 0, 1]
+      inverted:
+        type: terrace
+        source: half
+        points: [-1, 0, 1]
+        invert: true
+`)
+
+	// Select between -1 and 1 over [0, 1], blending 0.2 either side of each bound.
+	sel := func(c float64) float64 {
+		s := &Select{A: Const(-1), B: Const(1), Control: Const(c), Lower: 0, Upper: 1, Falloff: 0.2}
+		return s.Eval2(0, 0)
+	}
+
+	var moduleTests = []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"scalebias", graphValue(t, spec, "shifted"), 0.75},
+		{"clamp min", graphValue(t, spec, "floor"), -0.5},
+		{"clamp default max", graphValue(t, spec, "ceiling"), 0.75},
+		{"curve at a point", NewCurve(Const(0), curvePoints).Eval2(0, 0), 0.5},
+		{"curve between points", graphValue(t, spec, "curve"), 0.9375},
+		{"terrace", graphValue(t, spec, "terrace"), 0.25},
+		{"inverted terrace", graphValue(t, spec, "inverted"), 0.75},
+		{"select below", sel(-0.5), -1},
+		{"select inside", sel(0.5), 1},
+		{"select at the bound", sel(0), 0},
+		{"select in the falloff", sel(-0.1), -0.6875},
+		{"select above", sel(1.5), -1},
+	}
+
+	for _, tt := range moduleTests {
+		if math.Abs(tt.got-tt.want) > 1e-12 {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+var curvePoints = []ControlPoint{{1, 1}, {-1, -1}, {0, 0.5}}
+
+func graphValue(t *testing.T, spec GraphSpec, root string) float64 {
+	spec.Root = root
+
+	src, err := spec.Build(1)
+	if err != nil {
+		t.Fatalf("Expected module %q to build, got %v", root, err)
+	}
+
+	return src.Eval2(0, 0)
+}
+
+func TestGraphIs4D(t *testing.T) {
+	simplex, perlin := NewWithSeed(1), NewPerlinWithSeed(1)
+
+	var is4DTests = []struct {
+		name string
+		in   Source
+		want bool
+	}{
+		{"opensimplex", simplex, true},
+		{"perlin", perlin, false},
+		{"fractal", NewFractal(simplex), true},
+		{"fractal of perlin", NewFractal(perlin), false},
+		{"add", Add{simplex, Const(1)}, true},
+		{"add with perlin", Add{simplex, perlin}, false},
+		{"select", &Select{A: simplex, B: Const(0), Control: perlin}, false},
+		{"scaled", &ScaleInput{Source: simplex, X: 2, Y: 2, Z: 2, W: 2}, true},
+		{"flattened", &ScaleInput{Source: simplex, X: 2, Y: 2, Z: 2}, false},
+		{"turbulence", NewTurbulence(simplex, 1, 1, 1, 2), false},
+	}
+
+	for _, tt := range is4DTests {
+		if got := Is4D(tt.in); got != tt.want {
+			t.Errorf("%s: Expected Is4D %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestGraphExplicitZero(t *testing.T) {
+	spec := loadGraph(t, `
+terrain:
+  graph:
+    root: positive
+    modules:
+      base:
+        type: source
+        algorithm: opensimplex
+      positive:
+        type: clamp
+        source: base
+        min: 0
+        max: 1
+      flat:
+        type: scalebias
+        source: base
+        scale: 0
+        bias: 0.5
+`)
+
+	src, err := spec.Build(1)
+	if err != nil {
+		t.Fatalf("Expected the graph to build, got %v", err)
+	}
+
+	var negative int
+	for i := 0; i < 1000; i++ {
+		if v := src.Eval2(float64(i)*0.13, float64(i)*0.07); v < 0 {
+			negative++
+		}
+	}
+	if negative != 0 {
+		t.Errorf("Expected min: 0 to clamp every value at zero, got %d negative values", negative)
+	}
+
+	if v := graphValue(t, spec, "flat"); v != 0.5 {
+		t.Errorf("Expected scale: 0 to flatten the source onto its bias, got %v", v)
+	}
+}
@@ -0,0 +1,478 @@
+package genesis
+
+import (
+	"math"
+	"sort"
+)
+
+// The modules in this file are small Sources that transform or combine other
+// Sources. They hold no state of their own, so a graph built from them is as safe
+// for concurrent use as the generators at its leaves. Each also implements Source4,
+// and reports through Is4D whether its inputs really have a fourth dimension.
+
+// Const is a Source that returns the same value everywhere.
+type Const float64
+
+// Eval2 returns c.
+func (c Const) Eval2(x, y float64) float64 { return float64(c) }
+
+// Eval3 returns c.
+func (c Const) Eval3(x, y, z float64) float64 { return float64(c) }
+
+// Eval4 returns c.
+func (c Const) Eval4(x, y, z, w float64) float64 { return float64(c) }
+
+// ScaleInput multiplies the coordinates passed to Source, changing its frequency
+// independently along each axis. A zero W flattens the fourth dimension away.
+type ScaleInput struct {
+	Source     Source
+	X, Y, Z, W float64
+}
+
+// Eval2 returns Source at the scaled x,y.
+func (s *ScaleInput) Eval2(x, y float64) float64 {
+	return s.Source.Eval2(x*s.X, y*s.Y)
+}
+
+// Eval3 returns Source at the scaled x,y,z.
+func (s *ScaleInput) Eval3(x, y, z float64) float64 {
+	return s.Source.Eval3(x*s.X, y*s.Y, z*s.Z)
+}
+
+// Eval4 returns Source at the scaled x,y,z,w.
+func (s *ScaleInput) Eval4(x, y, z, w float64) float64 {
+	return eval4(s.Source, x*s.X, y*s.Y, z*s.Z, w*s.W)
+}
+
+// Is4D reports whether Source is 4D and W keeps its fourth dimension.
+func (s *ScaleInput) Is4D() bool { return s.W != 0 && Is4D(s.Source) }
+
+// Add sums its Sources.
+type Add []Source
+
+// Eval2 returns the sum of every Source at x,y.
+func (a Add) Eval2(x, y float64) float64 {
+	var v float64
+	for _, s := range a {
+		v += s.Eval2(x, y)
+	}
+	return v
+}
+
+// Eval3 returns the sum of every Source at x,y,z.
+func (a Add) Eval3(x, y, z float64) float64 {
+	var v float64
+	for _, s := range a {
+		v += s.Eval3(x, y, z)
+	}
+	return v
+}
+
+// Eval4 returns the sum of every Source at x,y,z,w.
+func (a Add) Eval4(x, y, z, w float64) float64 {
+	var v float64
+	for _, s := range a {
+		v += eval4(s, x, y, z, w)
+	}
+	return v
+}
+
+// Is4D reports whether every Source is 4D.
+func (a Add) Is4D() bool { return all4D(a...) }
+
+// Multiply multiplies its Sources together.
+type Multiply []Source
+
+// Eval2 returns the product of every Source at x,y.
+func (m Multiply) Eval2(x, y float64) float64 {
+	v := 1.0
+	for _, s := range m {
+		v *= s.Eval2(x, y)
+	}
+	return v
+}
+
+// Eval3 returns the product of every Source at x,y,z.
+func (m Multiply) Eval3(x, y, z float64) float64 {
+	v := 1.0
+	for _, s := range m {
+		v *= s.Eval3(x, y, z)
+	}
+	return v
+}
+
+// Eval4 returns the product of every Source at x,y,z,w.
+func (m Multiply) Eval4(x, y, z, w float64) float64 {
+	v := 1.0
+	for _, s := range m {
+		v *= eval4(s, x, y, z, w)
+	}
+	return v
+}
+
+// Is4D reports whether every Source is 4D.
+func (m Multiply) Is4D() bool { return all4D(m...) }
+
+// Min returns the lowest value of its Sources.
+type Min []Source
+
+// Eval2 returns the lowest Source value at x,y.
+func (m Min) Eval2(x, y float64) float64 {
+	v := math.Inf(1)
+	for _, s := range m {
+		v = math.Min(v, s.Eval2(x, y))
+	}
+	return v
+}
+
+// Eval3 returns the lowest Source value at x,y,z.
+func (m Min) Eval3(x, y, z float64) float64 {
+	v := math.Inf(1)
+	for _, s := range m {
+		v = math.Min(v, s.Eval3(x, y, z))
+	}
+	return v
+}
+
+// Eval4 returns the lowest Source value at x,y,z,w.
+func (m Min) Eval4(x, y, z, w float64) float64 {
+	v := math.Inf(1)
+	for _, s := range m {
+		v = math.Min(v, eval4(s, x, y, z, w))
+	}
+	return v
+}
+
+// Is4D reports whether every Source is 4D.
+func (m Min) Is4D() bool { return all4D(m...) }
+
+// Max returns the highest value of its Sources.
+type Max []Source
+
+// Eval2 returns the highest Source value at x,y.
+func (m Max) Eval2(x, y float64) float64 {
+	v := math.Inf(-1)
+	for _, s := range m {
+		v = math.Max(v, s.Eval2(x, y))
+	}
+	return v
+}
+
+// Eval3 returns the highest Source value at x,y,z.
+func (m Max) Eval3(x, y, z float64) float64 {
+	v := math.Inf(-1)
+	for _, s := range m {
+		v = math.Max(v, s.Eval3(x, y, z))
+	}
+	return v
+}
+
+// Eval4 returns the highest Source value at x,y,z,w.
+func (m Max) Eval4(x, y, z, w float64) float64 {
+	v := math.Inf(-1)
+	for _, s := range m {
+		v = math.Max(v, eval4(s, x, y, z, w))
+	}
+	return v
+}
+
+// Is4D reports whether every Source is 4D.
+func (m Max) Is4D() bool { return all4D(m...) }
+
+// Blend mixes A and B linearly. A Control value of -1 gives A, 1 gives B.
+type Blend struct {
+	A, B, Control Source
+}
+
+// Eval2 returns the blend of A and B at x,y.
+func (b *Blend) Eval2(x, y float64) float64 {
+	t := (b.Control.Eval2(x, y) + 1) / 2
+	return lerp(t, b.A.Eval2(x, y), b.B.Eval2(x, y))
+}
+
+// Eval3 returns the blend of A and B at x,y,z.
+func (b *Blend) Eval3(x, y, z float64) float64 {
+	t := (b.Control.Eval3(x, y, z) + 1) / 2
+	return lerp(t, b.A.Eval3(x, y, z), b.B.Eval3(x, y, z))
+}
+
+// Eval4 returns the blend of A and B at x,y,z,w.
+func (b *Blend) Eval4(x, y, z, w float64) float64 {
+	t := (eval4(b.Control, x, y, z, w) + 1) / 2
+	return lerp(t, eval4(b.A, x, y, z, w), eval4(b.B, x, y, z, w))
+}
+
+// Is4D reports whether A, B and Control are all 4D.
+func (b *Blend) Is4D() bool { return all4D(b.A, b.B, b.Control) }
+
+// Select returns B where Control lies within [Lower, Upper] and A everywhere else.
+// A non-zero Falloff smooths the transition over that distance either side of each
+// bound, so the seams between the two don't show.
+type Select struct {
+	A, B, Control Source
+	Lower, Upper  float64
+	Falloff       float64
+}
+
+// Eval2 returns A or B at x,y, depending on Control.
+func (s *Select) Eval2(x, y float64) float64 {
+	return s.pick(s.Control.Eval2(x, y),
+		func() float64 { return s.A.Eval2(x, y) },
+		func() float64 { return s.B.Eval2(x, y) },
+	)
+}
+
+// Eval3 returns A or B at x,y,z, depending on Control.
+func (s *Select) Eval3(x, y, z float64) float64 {
+	return s.pick(s.Control.Eval3(x, y, z),
+		func() float64 { return s.A.Eval3(x, y, z) },
+		func() float64 { return s.B.Eval3(x, y, z) },
+	)
+}
+
+// Eval4 returns A or B at x,y,z,w, depending on Control.
+func (s *Select) Eval4(x, y, z, w float64) float64 {
+	return s.pick(eval4(s.Control, x, y, z, w),
+		func() float64 { return eval4(s.A, x, y, z, w) },
+		func() float64 { return eval4(s.B, x, y, z, w) },
+	)
+}
+
+// Is4D reports whether A, B and Control are all 4D.
+func (s *Select) Is4D() bool { return all4D(s.A, s.B, s.Control) }
+
+func (s *Select) pick(c float64, a, b func() float64) float64 {
+	f := math.Min(s.Falloff, (s.Upper-s.Lower)/2)
+
+	switch {
+	case f > 0 && c > s.Lower-f && c < s.Lower+f:
+		t := scurve((c - (s.Lower - f)) / (2 * f))
+		return lerp(t, a(), b())
+	case f > 0 && c > s.Upper-f && c < s.Upper+f:
+		t := scurve((c - (s.Upper - f)) / (2 * f))
+		return lerp(t, b(), a())
+	case c >= s.Lower && c <= s.Upper:
+		return b()
+	}
+
+	return a()
+}
+
+// ScaleBias multiplies the output of Source by Scale and then adds Bias.
+type ScaleBias struct {
+	Source      Source
+	Scale, Bias float64
+}
+
+// Eval2 returns the scaled and biased Source at x,y.
+func (s *ScaleBias) Eval2(x, y float64) float64 {
+	return s.Source.Eval2(x, y)*s.Scale + s.Bias
+}
+
+// Eval3 returns the scaled and biased Source at x,y,z.
+func (s *ScaleBias) Eval3(x, y, z float64) float64 {
+	return s.Source.Eval3(x, y, z)*s.Scale + s.Bias
+}
+
+// Eval4 returns the scaled and biased Source at x,y,z,w.
+func (s *ScaleBias) Eval4(x, y, z, w float64) float64 {
+	return eval4(s.Source, x, y, z, w)*s.Scale + s.Bias
+}
+
+// Is4D reports whether Source is 4D.
+func (s *ScaleBias) Is4D() bool { return Is4D(s.Source) }
+
+// Clamp limits the output of Source to [Min, Max].
+type Clamp struct {
+	Source   Source
+	Min, Max float64
+}
+
+// Eval2 returns the clamped Source at x,y.
+func (c *Clamp) Eval2(x, y float64) float64 {
+	return math.Max(c.Min, math.Min(c.Max, c.Source.Eval2(x, y)))
+}
+
+// Eval3 returns the clamped Source at x,y,z.
+func (c *Clamp) Eval3(x, y, z float64) float64 {
+	return math.Max(c.Min, math.Min(c.Max, c.Source.Eval3(x, y, z)))
+}
+
+// Eval4 returns the clamped Source at x,y,z,w.
+func (c *Clamp) Eval4(x, y, z, w float64) float64 {
+	return math.Max(c.Min, math.Min(c.Max, eval4(c.Source, x, y, z, w)))
+}
+
+// Is4D reports whether Source is 4D.
+func (c *Clamp) Is4D() bool { return Is4D(c.Source) }
+
+// Invert negates the output of Source.
+type Invert struct {
+	Source Source
+}
+
+// Eval2 returns the negated Source at x,y.
+func (i *Invert) Eval2(x, y float64) float64 {
+	return -i.Source.Eval2(x, y)
+}
+
+// Eval3 returns the negated Source at x,y,z.
+func (i *Invert) Eval3(x, y, z float64) float64 {
+	return -i.Source.Eval3(x, y, z)
+}
+
+// Eval4 returns the negated Source at x,y,z,w.
+func (i *Invert) Eval4(x, y, z, w float64) float64 {
+	return -eval4(i.Source, x, y, z, w)
+}
+
+// Is4D reports whether Source is 4D.
+func (i *Invert) Is4D() bool { return Is4D(i.Source) }
+
+// ControlPoint maps an input value of a Curve to an output value.
+type ControlPoint struct {
+	In, Out float64
+}
+
+// Curve remaps the output of Source through a smooth curve passing through Points,
+// using cubic interpolation between them. Values beyond the first or last point are
+// clamped to it. NewCurve keeps Points sorted.
+type Curve struct {
+	Source Source
+	Points []ControlPoint
+}
+
+// NewCurve returns a Curve over src through points, which need not be sorted.
+func NewCurve(src Source, points []ControlPoint) *Curve {
+	p := append([]ControlPoint(nil), points...)
+	sort.Slice(p, func(i, j int) bool { return p[i].In < p[j].In })
+
+	return &Curve{Source: src, Points: p}
+}
+
+// Eval2 returns the remapped Source at x,y.
+func (c *Curve) Eval2(x, y float64) float64 {
+	return c.apply(c.Source.Eval2(x, y))
+}
+
+// Eval3 returns the remapped Source at x,y,z.
+func (c *Curve) Eval3(x, y, z float64) float64 {
+	return c.apply(c.Source.Eval3(x, y, z))
+}
+
+// Eval4 returns the remapped Source at x,y,z,w.
+func (c *Curve) Eval4(x, y, z, w float64) float64 {
+	return c.apply(eval4(c.Source, x, y, z, w))
+}
+
+// Is4D reports whether Source is 4D.
+func (c *Curve) Is4D() bool { return Is4D(c.Source) }
+
+func (c *Curve) apply(v float64) float64 {
+	n := len(c.Points)
+	switch {
+	case n == 0:
+		return v
+	case v <= c.Points[0].In:
+		return c.Points[0].Out
+	case v >= c.Points[n-1].In:
+		return c.Points[n-1].Out
+	}
+
+	i := sort.Search(n, func(i int) bool { return c.Points[i].In > v })
+	p1, p2 := c.Points[i-1], c.Points[i]
+	p0, p3 := c.Points[clampIndex(i-2, n)], c.Points[clampIndex(i+1, n)]
+
+	t := (v - p1.In) / (p2.In - p1.In)
+	return cubic(t, p0.Out, p1.Out, p2.Out, p3.Out)
+}
+
+// Terrace remaps the output of Source into flat terraces separated by steep rises at
+// each of Points, which must be sorted. Invert flips each step so the flats sit at
+// the top of the rise instead of the bottom.
+type Terrace struct {
+	Source Source
+	Points []float64
+	Invert bool
+}
+
+// Eval2 returns the terraced Source at x,y.
+func (t *Terrace) Eval2(x, y float64) float64 {
+	return t.apply(t.Source.Eval2(x, y))
+}
+
+// Eval3 returns the terraced Source at x,y,z.
+func (t *Terrace) Eval3(x, y, z float64) float64 {
+	return t.apply(t.Source.Eval3(x, y, z))
+}
+
+// Eval4 returns the terraced Source at x,y,z,w.
+func (t *Terrace) Eval4(x, y, z, w float64) float64 {
+	return t.apply(eval4(t.Source, x, y, z, w))
+}
+
+// Is4D reports whether Source is 4D.
+func (t *Terrace) Is4D() bool { return Is4D(t.Source) }
+
+func (t *Terrace) apply(v float64) float64 {
+	n := len(t.Points)
+	switch {
+	case n < 2:
+		return v
+	case v <= t.Points[0]:
+		return t.Points[0]
+	case v >= t.Points[n-1]:
+		return t.Points[n-1]
+	}
+
+	i := sort.SearchFloat64s(t.Points, v)
+	lo, hi := t.Points[i-1], t.Points[i]
+
+	a := (v - lo) / (hi - lo)
+	if t.Invert {
+		a = 1 - a
+		lo, hi = hi, lo
+	}
+
+	return lerp(a*a, lo, hi)
+}
+
+// NewTurbulence returns a Warp that jitters the coordinates passed to src with a
+// separately seeded Perlin fBm field, in the manner of libnoise's Turbulence module.
+// Frequency sets how quickly the jitter changes, power how far it moves a point and
+// roughness the number of octaves in the field.
+func NewTurbulence(src Source, seed int64, frequency, power float64, roughness int) *Warp {
+	field := NewFractal(NewPerlinWithSeed(seed))
+	field.Frequency = frequency
+	field.Octaves = roughness
+
+	return &Warp{
+		Source:   src,
+		Field:    field,
+		Strength: power,
+		Depth:    1,
+	}
+}
+
+// scurve is the cubic smoothstep, 3t^2 - 2t^3.
+func scurve(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// cubic interpolates between p1 and p2 using p0 and p3 to shape the tangents.
+func cubic(t, p0, p1, p2, p3 float64) float64 {
+	p := (p3 - p2) - (p0 - p1)
+	q := (p0 - p1) - p
+	r := p2 - p0
+	return p*t*t*t + q*t*t + r*t + p1
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
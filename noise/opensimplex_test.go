@@ -0,0 +1,223 @@
+package genesis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// finiteDifferenceStep is small enough for the central difference to track the
+// analytic derivative closely, but large enough to stay clear of rounding noise.
+const finiteDifferenceStep = 1e-6
+
+// referenceTests are samples of Kurt Spencer's reference implementation with its
+// default seed, which New reproduces.
+var referenceTests = []struct {
+	in   []float64
+	want float64
+}{
+	{[]float64{11.929785848992402, 64.23283223043418}, -0.362998740165833},
+	{[]float64{-582.3318754472291, 88.17349646708239}, -0.6470986017029651},
+	{[]float64{-674.7727495559923, 626.8040180081955}, -0.38167275274661644},
+	{[]float64{174.69211864220634, 333.4421809004016}, -0.47728608099549463},
+	{[]float64{-229.83465230445677, -683.013450165731, 224.4622045892053}, 0.08959722891335488},
+	{[]float64{-463.4302729420252, 165.833206412602, -935.2322915147604}, -0.4793946961954098},
+	{[]float64{-297.16797812640607, 524.0204605210286, 285.2726622814827}, -0.517620608096738},
+	{[]float64{623.5455318147488, 407.1405218599899, -227.7585708501879}, 0.01306016945303215},
+	{[]float64{392.52092671172466, -272.8086053365442, -752.3757964042168, -223.44951788941515}, 0.23223152721643162},
+	{[]float64{511.4683208016535, 16.756960716793444, -213.47917111440574, 601.0825885276106}, -0.4196301633470657},
+	{[]float64{439.06194473922966, -129.05364191746193, -969.7858095531022, -594.3225962440677}, 0.1296387290262017},
+	{[]float64{-742.7256589417708, -284.50003408162974, 271.4108520686913, 634.6805223772299}, -0.2963401836610621},
+}
+
+func TestReferenceValues(t *testing.T) {
+	n := New()
+
+	for _, tt := range referenceTests {
+		var got float64
+		switch p := tt.in; len(p) {
+		case 2:
+			got = n.Eval2(p[0], p[1])
+		case 3:
+			got = n.Eval3(p[0], p[1], p[2])
+		case 4:
+			got = n.Eval4(p[0], p[1], p[2], p[3])
+		}
+
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Expected %v at %v, got %v", tt.want, tt.in, got)
+		}
+	}
+}
+
+func TestEval2WithDerivative(t *testing.T) {
+	n := NewWithSeed(18006665432)
+	r := rand.New(rand.NewSource(1))
+	h := finiteDifferenceStep
+
+	for i := 0; i < 1000; i++ {
+		x := r.Float64()*200 - 100
+		y := r.Float64()*200 - 100
+
+		v, dx, dy := n.Eval2WithDerivative(x, y)
+
+		if want := n.Eval2(x, y); v != want {
+			t.Fatalf("Expected value %v at (%v, %v), got %v", want, x, y, v)
+		}
+
+		fdx := (n.Eval2(x+h, y) - n.Eval2(x-h, y)) / (2 * h)
+		fdy := (n.Eval2(x, y+h) - n.Eval2(x, y-h)) / (2 * h)
+
+		if math.Abs(dx-fdx) > 1e-5 || math.Abs(dy-fdy) > 1e-5 {
+			t.Errorf("Expected derivative (%v, %v) at (%v, %v), got (%v, %v)", fdx, fdy, x, y, dx, dy)
+		}
+	}
+}
+
+func TestEval3WithDerivative(t *testing.T) {
+	n := NewWithSeed(18006665432)
+	r := rand.New(rand.NewSource(1))
+	h := finiteDifferenceStep
+
+	for i := 0; i < 1000; i++ {
+		x := r.Float64()*200 - 100
+		y := r.Float64()*200 - 100
+		z := r.Float64()*200 - 100
+
+		v, dx, dy, dz := n.Eval3WithDerivative(x, y, z)
+
+		if want := n.Eval3(x, y, z); v != want {
+			t.Fatalf("Expected value %v at (%v, %v, %v), got %v", want, x, y, z, v)
+		}
+
+		fdx := (n.Eval3(x+h, y, z) - n.Eval3(x-h, y, z)) / (2 * h)
+		fdy := (n.Eval3(x, y+h, z) - n.Eval3(x, y-h, z)) / (2 * h)
+		fdz := (n.Eval3(x, y, z+h) - n.Eval3(x, y, z-h)) / (2 * h)
+
+		if math.Abs(dx-fdx) > 1e-5 || math.Abs(dy-fdy) > 1e-5 || math.Abs(dz-fdz) > 1e-5 {
+			t.Errorf("Expected derivative (%v, %v, %v) at (%v, %v, %v), got (%v, %v, %v)",
+				fdx, fdy, fdz, x, y, z, dx, dy, dz)
+		}
+	}
+}
+
+func TestLatticePeriod(t *testing.T) {
+	table := NewWithSeed(5)
+
+	c := DefaultConfig()
+	c.Lattice = LatticeHash
+	hashed := NewWithConfig(5, c)
+
+	// Moving 256 units along one axis of the skewed 3D lattice is the shortest
+	// offset over which the table lattice repeats.
+	ox, oy, oz := 256+256*squishConstant3D, 256*squishConstant3D, 256*squishConstant3D
+
+	var tableRepeats, hashRepeats int
+	for i := 0; i < 100; i++ {
+		x, y, z := float64(i)*0.731+0.1, float64(i)*0.377+0.2, float64(i)*0.119+0.3
+
+		if math.Abs(table.Eval3(x, y, z)-table.Eval3(x+ox, y+oy, z+oz)) < 1e-9 {
+			tableRepeats++
+		}
+		if math.Abs(hashed.Eval3(x, y, z)-hashed.Eval3(x+ox, y+oy, z+oz)) < 1e-9 {
+			hashRepeats++
+		}
+	}
+
+	if tableRepeats != 100 {
+		t.Errorf("Expected the table lattice to repeat, got %d repeats out of 100", tableRepeats)
+	}
+	if hashRepeats != 0 {
+		t.Errorf("Expected the hashed lattice not to repeat, got %d repeats out of 100", hashRepeats)
+	}
+}
+
+func benchmarkLattice(b *testing.B, lattice LatticeMode) {
+	c := DefaultConfig()
+	c.Lattice = lattice
+	n := NewWithConfig(5, c)
+
+	var sink float64
+	for i := 0; i < b.N; i++ {
+		sink += n.Eval3(float64(i)*0.731, float64(i)*0.377, float64(i)*0.119)
+	}
+	_ = sink
+}
+
+func BenchmarkEval3Table(b *testing.B) { benchmarkLattice(b, LatticeTable) }
+
+func BenchmarkEval3Hash(b *testing.B) { benchmarkLattice(b, LatticeHash) }
+
+func TestStretchBoundary(t *testing.T) {
+	const step = 1e-5
+
+	for _, stretch := range []float64{-1.0 / 6, -0.33} {
+		c := DefaultConfig()
+		c.Stretch = stretch
+		n := NewWithConfig(7, c)
+
+		if err := n.Config().Validate(); err != nil {
+			t.Errorf("stretch %v: Expected a valid derived config, got %v", stretch, err)
+			continue
+		}
+
+		var jump2, jump3 float64
+		prev2, prev3 := n.Eval2(-2, 0.3), n.Eval3(-2, 0.3, 0.7)
+		for x := -2 + step; x < 2; x += step {
+			v2, v3 := n.Eval2(x, 0.3), n.Eval3(x, 0.3, 0.7)
+			if math.IsNaN(v2) || math.IsInf(v2, 0) || math.IsNaN(v3) || math.IsInf(v3, 0) {
+				t.Fatalf("stretch %v: Expected finite noise at x %v, got %v and %v", stretch, x, v2, v3)
+			}
+			jump2 = math.Max(jump2, math.Abs(v2-prev2))
+			jump3 = math.Max(jump3, math.Abs(v3-prev3))
+			prev2, prev3 = v2, v3
+		}
+
+		if jump2 > 1e-3 || jump3 > 1e-3 {
+			t.Errorf("stretch %v: Expected continuous noise, got jumps of %v in Eval2 and %v in Eval3", stretch, jump2, jump3)
+		}
+	}
+}
+
+func TestStretchContinuity(t *testing.T) {
+	const step = 1e-5
+
+	for _, stretch := range []float64{stretchConstant3D, -0.2, -0.25, -0.3} {
+		c := DefaultConfig()
+		c.Stretch = stretch
+		n := NewWithConfig(7, c)
+
+		if err := n.Config().Validate(); err != nil {
+			t.Errorf("stretch %v: Expected a valid derived config, got %v", stretch, err)
+		}
+
+		var jump float64
+		prev := n.Eval3(-2, 0.3, 0.7)
+		for x := -2 + step; x < 2; x += step {
+			v := n.Eval3(x, 0.3, 0.7)
+			jump = math.Max(jump, math.Abs(v-prev))
+			prev = v
+		}
+
+		if jump > 1e-3 {
+			t.Errorf("stretch %v: Expected continuous noise, got a jump of %v between neighbouring samples", stretch, jump)
+		}
+	}
+
+	var invalidTests = []struct {
+		name            string
+		stretch, squish float64
+	}{
+		{"mismatched squish", stretchConstant3D, 0.25},
+		{"weak stretch", -0.1, SquishFor(-0.1)},
+		{"singular stretch", -1.0 / 3, 0},
+	}
+
+	for _, tt := range invalidTests {
+		c := DefaultConfig()
+		c.Stretch, c.Squish = tt.stretch, tt.squish
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected an error for a %s, got nil", tt.name)
+		}
+	}
+}
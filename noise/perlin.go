@@ -0,0 +1,103 @@
+package genesis
+
+import (
+	"math"
+)
+
+// Perlin is Ken Perlin's improved gradient noise (2002). It is cheaper than
+// OpenSimplex but shows more axis-aligned artifacts.
+type Perlin struct {
+	perm lattice
+}
+
+// NewPerlinWithSeed returns a Perlin instance with a 64-bit seed. Two Perlin instances
+// with the same seed will have the same output.
+func NewPerlinWithSeed(seed int64) *Perlin {
+	return &Perlin{perm: permutation(seed)}
+}
+
+// Eval2 returns a random noise value in two dimensions.
+func (p *Perlin) Eval2(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int32(xf), int32(yf)
+	x, y = x-xf, y-yf
+	u, v := fade(x), fade(y)
+
+	n00 := grad2(p.perm.hash2(xi, yi), x, y)
+	n10 := grad2(p.perm.hash2(xi+1, yi), x-1, y)
+	n01 := grad2(p.perm.hash2(xi, yi+1), x, y-1)
+	n11 := grad2(p.perm.hash2(xi+1, yi+1), x-1, y-1)
+
+	return lerp(v, lerp(u, n00, n10), lerp(u, n01, n11))
+}
+
+// Eval3 returns a random noise value in three dimensions.
+func (p *Perlin) Eval3(x, y, z float64) float64 {
+	xf, yf, zf := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int32(xf), int32(yf), int32(zf)
+	x, y, z = x-xf, y-yf, z-zf
+	u, v, w := fade(x), fade(y), fade(z)
+
+	n000 := grad3(p.perm.hash3(xi, yi, zi), x, y, z)
+	n100 := grad3(p.perm.hash3(xi+1, yi, zi), x-1, y, z)
+	n010 := grad3(p.perm.hash3(xi, yi+1, zi), x, y-1, z)
+	n110 := grad3(p.perm.hash3(xi+1, yi+1, zi), x-1, y-1, z)
+	n001 := grad3(p.perm.hash3(xi, yi, zi+1), x, y, z-1)
+	n101 := grad3(p.perm.hash3(xi+1, yi, zi+1), x-1, y, z-1)
+	n011 := grad3(p.perm.hash3(xi, yi+1, zi+1), x, y-1, z-1)
+	n111 := grad3(p.perm.hash3(xi+1, yi+1, zi+1), x-1, y-1, z-1)
+
+	return lerp(w,
+		lerp(v, lerp(u, n000, n100), lerp(u, n010, n110)),
+		lerp(v, lerp(u, n001, n101), lerp(u, n011, n111)),
+	)
+}
+
+// grad2 picks one of eight gradients around the unit square and dots it with x,y.
+func grad2(h int16, x, y float64) float64 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+// grad3 picks one of the twelve cube-edge gradients from Perlin's reference
+// implementation and dots it with x,y,z.
+func grad3(h int16, x, y, z float64) float64 {
+	h &= 15
+
+	u := y
+	if h < 8 {
+		u = x
+	}
+
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+
+	return u + v
+}
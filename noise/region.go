@@ -0,0 +1,88 @@
+package genesis
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Region describes an evenly spaced, rectangular grid of sample points in the plane
+// at height Z. Sample (i, j) lies at (X + i*Step, Y + j*Step, Z).
+type Region struct {
+	X      float64
+	Y      float64
+	Z      float64
+	Width  int
+	Height int
+	Step   float64
+}
+
+// Len returns the number of samples in r.
+func (r Region) Len() int {
+	return r.Width * r.Height
+}
+
+// FillRegion evaluates src with Eval3 at every sample of r and writes the results into
+// dst in row-major order. dst must hold at least r.Len() values. The rows are spread
+// over workers goroutines as FillRows describes; src must be safe for concurrent use,
+// which every Source in this package is.
+func FillRegion(src Source, dst []float64, r Region, workers int) error {
+	if r.Width < 0 || r.Height < 0 {
+		return fmt.Errorf("invalid region size %dx%d", r.Width, r.Height)
+	}
+	if len(dst) < r.Len() {
+		return fmt.Errorf("destination holds %d values, region needs %d", len(dst), r.Len())
+	}
+
+	return FillRows(dst[:r.Len()], r.Width, r.Height, workers, func(j int, row []float64) {
+		y := r.Y + float64(j)*r.Step
+		for i := range row {
+			row[i] = src.Eval3(r.X+float64(i)*r.Step, y, r.Z)
+		}
+	})
+}
+
+// FillRows calls fill once for each row of a width by height grid stored in dst in
+// row-major order, passing the row's index and its slice of dst.
+//
+// Rows are handed out to workers goroutines as they become free; a workers value below
+// 1 uses one goroutine per CPU. As long as fill computes each row from its index
+// alone, the output is identical to a serial fill regardless of the number of
+// workers. fill must be safe for concurrent use.
+func FillRows(dst []float64, width, height, workers int, fill func(j int, row []float64)) error {
+	if width < 0 || height < 0 {
+		return fmt.Errorf("invalid grid size %dx%d", width, height)
+	}
+	if len(dst) < width*height {
+		return fmt.Errorf("destination holds %d values, grid needs %d", len(dst), width*height)
+	}
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > height {
+		workers = height
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := int(atomic.AddInt64(&next, 1))
+				if j >= height {
+					return
+				}
+				fill(j, dst[j*width:(j+1)*width])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
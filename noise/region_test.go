@@ -0,0 +1,40 @@
+package genesis
+
+import (
+	"testing"
+)
+
+func TestFillRegionMatchesSerial(t *testing.T) {
+	src := NewFractal(NewWithSeed(18006665432))
+	r := Region{X: -3.5, Y: 12.25, Z: 0.5, Width: 97, Height: 61, Step: 0.37}
+
+	serial := make([]float64, r.Len())
+	for j := 0; j < r.Height; j++ {
+		for i := 0; i < r.Width; i++ {
+			serial[j*r.Width+i] = src.Eval3(r.X+float64(i)*r.Step, r.Y+float64(j)*r.Step, r.Z)
+		}
+	}
+
+	var fillTests = []int{1, 2, 7, 64, 0}
+
+	for _, workers := range fillTests {
+		dst := make([]float64, r.Len())
+		if err := FillRegion(src, dst, r, workers); err != nil {
+			t.Fatalf("FillRegion with %d workers failed: %v", workers, err)
+		}
+
+		for i := range dst {
+			if dst[i] != serial[i] {
+				t.Fatalf("Expected %v at index %d with %d workers, got %v", serial[i], i, workers, dst[i])
+			}
+		}
+	}
+}
+
+func TestFillRegionShortDestination(t *testing.T) {
+	r := Region{Width: 10, Height: 10, Step: 1}
+
+	if err := FillRegion(New(), make([]float64, 99), r, 1); err == nil {
+		t.Errorf("Expected an error for a short destination, got nil")
+	}
+}
@@ -0,0 +1,123 @@
+package genesis
+
+import (
+	"fmt"
+)
+
+// Source is anything that produces a continuous noise value for a point in
+// space. *Noise is the reference implementation; wrappers such as Fractal
+// accept any Source so they can be layered on top of each other.
+type Source interface {
+	Eval2(x, y float64) float64
+	Eval3(x, y, z float64) float64
+}
+
+// Source4 is a Source with a fourth dimension. Sampling two of its axes around a
+// circle gives a sequence that returns smoothly to where it started, which is how
+// looping animations are made.
+type Source4 interface {
+	Source
+	Eval4(x, y, z, w float64) float64
+}
+
+// Is4D reports whether s really varies along all four axes of Eval4. Wrappers such
+// as Fractal, Warp and the graph modules implement Source4 whatever they wrap, and
+// fall back to Eval3 when their inputs have no fourth dimension, so a type assertion
+// alone cannot tell; they report through an Is4D method instead.
+func Is4D(s Source) bool {
+	switch v := s.(type) {
+	case interface{ Is4D() bool }:
+		return v.Is4D()
+	case Source4:
+		return true
+	}
+
+	return false
+}
+
+// all4D reports whether every one of sources is 4D.
+func all4D(sources ...Source) bool {
+	for _, s := range sources {
+		if !Is4D(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// eval4 evaluates s at x,y,z,w, or at x,y,z when it has no fourth dimension.
+func eval4(s Source, x, y, z, w float64) float64 {
+	if s4, ok := s.(Source4); ok {
+		return s4.Eval4(x, y, z, w)
+	}
+	return s.Eval3(x, y, z)
+}
+
+// Algorithms lists the names accepted by NewSource.
+var Algorithms = []string{"opensimplex", "perlin", "value", "worley", "gabor"}
+
+// NewSource returns the Source implementing the named algorithm, seeded with seed.
+// The lattice algorithms all draw their lattice from the same seeded permutation, so
+// comparing them on one seed is a fair comparison.
+func NewSource(algorithm string, seed int64) (Source, error) {
+	switch algorithm {
+	case "opensimplex", "":
+		return NewWithSeed(seed), nil
+	case "perlin":
+		return NewPerlinWithSeed(seed), nil
+	case "value":
+		return NewValueWithSeed(seed), nil
+	case "worley":
+		return NewWorleyWithSeed(seed), nil
+	case "gabor":
+		return NewGaborWithSeed(seed), nil
+	}
+
+	return nil, fmt.Errorf("unknown noise algorithm %q", algorithm)
+}
+
+// permutation shuffles the integers [0, 256) using a 64-bit LCG seeded with seed.
+func permutation(seed int64) []int16 {
+	perm := make([]int16, 256)
+
+	source := make([]int16, 256)
+	for i := range source {
+		source[i] = int16(i)
+	}
+
+	seed = seed*6364136223846793005 + 1442695040888963407
+	seed = seed*6364136223846793005 + 1442695040888963407
+	seed = seed*6364136223846793005 + 1442695040888963407
+	for i := int32(255); i >= 0; i-- {
+		seed = seed*6364136223846793005 + 1442695040888963407
+		r := int32((seed + 31) % int64(i+1))
+		if r < 0 {
+			r += i + 1
+		}
+
+		perm[i] = source[r]
+		source[r] = source[i]
+	}
+
+	return perm
+}
+
+// lattice hashes integer lattice coordinates through a seeded permutation.
+type lattice []int16
+
+func (p lattice) hash2(x, y int32) int16 {
+	return p[(int32(p[x&0xFF])+y)&0xFF]
+}
+
+func (p lattice) hash3(x, y, z int32) int16 {
+	return p[(int32(p[(int32(p[x&0xFF])+y)&0xFF])+z)&0xFF]
+}
+
+// fade is Perlin's quintic smoothstep, 6t^5 - 15t^4 + 10t^3.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
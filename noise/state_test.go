@@ -0,0 +1,120 @@
+package genesis
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func sameOutput(t *testing.T, a, b *Noise) {
+	for i := 0; i < 500; i++ {
+		x, y, z, w := float64(i)*0.731-100, float64(i)*-0.377+50, float64(i)*0.119, float64(i)*0.053
+
+		if a.Eval2(x, y) != b.Eval2(x, y) ||
+			a.Eval3(x, y, z) != b.Eval3(x, y, z) ||
+			a.Eval4(x, y, z, w) != b.Eval4(x, y, z, w) {
+			t.Fatalf("Expected identical output at (%v, %v, %v, %v)", x, y, z, w)
+		}
+	}
+}
+
+func TestNewWithPermMatchesSeed(t *testing.T) {
+	seeded := NewWithSeed(18006665432)
+
+	loaded, err := NewWithPerm(seeded.Perm())
+	if err != nil {
+		t.Fatalf("Expected a valid permutation, got %v", err)
+	}
+
+	sameOutput(t, seeded, loaded)
+}
+
+func TestNewWithPermValidation(t *testing.T) {
+	repeated := New().Perm()
+	repeated[7] = repeated[8]
+
+	outOfRange := New().Perm()
+	outOfRange[3] = 256
+
+	var permTests = []struct {
+		name string
+		in   []int16
+	}{
+		{"empty", nil},
+		{"short", New().Perm()[:255]},
+		{"long", append(New().Perm(), 0)},
+		{"repeated", repeated},
+		{"out of range", outOfRange},
+	}
+
+	for _, tt := range permTests {
+		if _, err := NewWithPerm(tt.in); err == nil {
+			t.Errorf("Expected an error for a %s permutation, got nil", tt.name)
+		}
+	}
+}
+
+func TestNoiseStateRoundTrip(t *testing.T) {
+	c := DefaultConfig()
+	c.Stretch = -0.2
+	c.Normalization = NormalizeClamp
+	original := NewWithConfig(42, c)
+
+	j, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Expected JSON encoding to succeed, got %v", err)
+	}
+
+	fromJSON := new(Noise)
+	if err := json.Unmarshal(j, fromJSON); err != nil {
+		t.Fatalf("Expected JSON decoding to succeed, got %v", err)
+	}
+	sameOutput(t, original, fromJSON)
+
+	b, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Expected binary encoding to succeed, got %v", err)
+	}
+
+	fromBinary := new(Noise)
+	if err := fromBinary.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Expected binary decoding to succeed, got %v", err)
+	}
+	sameOutput(t, original, fromBinary)
+}
+
+func TestNoiseStateRejectsBadPerm(t *testing.T) {
+	var st noiseState
+	j, _ := json.Marshal(New())
+	json.Unmarshal(j, &st)
+
+	st.Perm[0] = st.Perm[1]
+	bad, _ := json.Marshal(st)
+
+	if err := json.Unmarshal(bad, new(Noise)); err == nil {
+		t.Errorf("Expected an error for a repeated permutation entry, got nil")
+	}
+}
+
+func TestNoiseStateLattice(t *testing.T) {
+	c := DefaultConfig()
+	c.Lattice = LatticeHash
+	original := NewWithConfig(42, c)
+
+	b, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Expected binary encoding to succeed, got %v", err)
+	}
+
+	loaded := new(Noise)
+	if err := loaded.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Expected binary decoding to succeed, got %v", err)
+	}
+	sameOutput(t, original, loaded)
+
+	// Version 1 states predate the lattice option and always used the table.
+	v1, _ := json.Marshal(noiseState{Version: 1, Perm: original.Perm(), Config: Config{Stretch: c.Stretch, Squish: c.Squish, Norm: c.Norm}})
+	if err := json.Unmarshal(v1, loaded); err != nil {
+		t.Fatalf("Expected a version 1 state to load, got %v", err)
+	}
+	sameOutput(t, NewWithSeed(42), loaded)
+}
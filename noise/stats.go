@@ -0,0 +1,126 @@
+package genesis
+
+import (
+	"math"
+)
+
+// Stats summarises a grid of noise samples, to help pick parameters for a Source.
+type Stats struct {
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+
+	// Histogram counts the samples falling in each of its equal-width bins between
+	// Min and Max.
+	Histogram []int
+
+	// FeatureSize estimates the dominant wavelength of the samples, in samples. It is
+	// four times the lag at which the autocorrelation along rows and columns first
+	// crosses zero, which is exact for a sine wave. FeatureSize is 0 when the
+	// autocorrelation doesn't cross zero within half the grid, meaning the features
+	// are larger than the grid can show.
+	FeatureSize float64
+}
+
+// Analyze computes Stats for a width x height grid of values in row-major order, such
+// as one filled by FillRegion, using the given number of histogram bins. An empty or
+// invalid grid gives zero Stats, and a bins value below 1 no histogram.
+func Analyze(values []float64, width, height, bins int) Stats {
+	if bins < 0 {
+		bins = 0
+	}
+
+	s := Stats{
+		Min:       math.Inf(1),
+		Max:       math.Inf(-1),
+		Histogram: make([]int, bins),
+	}
+
+	n := width * height
+	if width <= 0 || height <= 0 || len(values) < n {
+		return Stats{Histogram: s.Histogram}
+	}
+	values = values[:n]
+
+	var sum float64
+	for _, v := range values {
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+		sum += v
+	}
+	s.Mean = sum / float64(n)
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(sq / float64(n))
+
+	if bins > 0 {
+		span := s.Max - s.Min
+		for _, v := range values {
+			b := bins - 1
+			if span > 0 {
+				b = int(float64(bins) * (v - s.Min) / span)
+			}
+			if b >= bins {
+				b = bins - 1
+			}
+			s.Histogram[b]++
+		}
+	}
+
+	s.FeatureSize = 4 * firstZeroCrossing(values, width, height, s.Mean, sq)
+
+	return s
+}
+
+// firstZeroCrossing returns the smallest lag, interpolated between whole samples, at
+// which the autocorrelation of the grid along both axes becomes negative. variance is
+// the summed squared deviation from mean.
+func firstZeroCrossing(values []float64, width, height int, mean, variance float64) float64 {
+	if variance == 0 {
+		return 0
+	}
+
+	maxLag := width / 2
+	if height/2 > maxLag {
+		maxLag = height / 2
+	}
+
+	prev := 1.0
+	for lag := 1; lag <= maxLag; lag++ {
+		var sum float64
+		var pairs int
+
+		if lag < width {
+			for j := 0; j < height; j++ {
+				row := values[j*width : (j+1)*width]
+				for i := lag; i < width; i++ {
+					sum += (row[i] - mean) * (row[i-lag] - mean)
+				}
+			}
+			pairs += (width - lag) * height
+		}
+
+		if lag < height {
+			for j := lag; j < height; j++ {
+				for i := 0; i < width; i++ {
+					sum += (values[j*width+i] - mean) * (values[(j-lag)*width+i] - mean)
+				}
+			}
+			pairs += (height - lag) * width
+		}
+
+		// Normalise by the number of pairs so shorter overlaps at long lags aren't
+		// biased towards zero.
+		r := sum / float64(pairs) / (variance / float64(len(values)))
+		if r <= 0 {
+			return float64(lag-1) + prev/(prev-r)
+		}
+		prev = r
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package genesis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeSine(t *testing.T) {
+	const width, height, wavelength = 128, 128, 32.0
+
+	values := make([]float64, width*height)
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			values[j*width+i] = math.Sin(2*math.Pi*float64(i)/wavelength) * math.Sin(2*math.Pi*float64(j)/wavelength)
+		}
+	}
+
+	s := Analyze(values, width, height, 4)
+
+	if s.Min < -1 || s.Min > -0.99 || s.Max > 1 || s.Max < 0.99 {
+		t.Errorf("Expected a range of about [-1, 1], got [%v, %v]", s.Min, s.Max)
+	}
+	if math.Abs(s.Mean) > 1e-9 {
+		t.Errorf("Expected a mean of 0, got %v", s.Mean)
+	}
+	if math.Abs(s.StdDev-0.5) > 1e-9 {
+		t.Errorf("Expected a standard deviation of 0.5, got %v", s.StdDev)
+	}
+
+	total := 0
+	for _, c := range s.Histogram {
+		total += c
+	}
+	if total != width*height {
+		t.Errorf("Expected the histogram to count %d samples, got %d", width*height, total)
+	}
+
+	// The overlap shrinks as the lag grows, so the estimate is only close.
+	if math.Abs(s.FeatureSize-wavelength) > wavelength*0.1 {
+		t.Errorf("Expected a feature size near %v, got %v", wavelength, s.FeatureSize)
+	}
+}
+
+func TestAnalyzeConstant(t *testing.T) {
+	values := []float64{0.5, 0.5, 0.5, 0.5}
+
+	s := Analyze(values, 2, 2, 3)
+
+	if s.Min != 0.5 || s.Max != 0.5 || s.StdDev != 0 || s.FeatureSize != 0 {
+		t.Errorf("Expected a flat summary, got %+v", s)
+	}
+	if s.Histogram[2] != 4 {
+		t.Errorf("Expected every sample in the last bin, got %v", s.Histogram)
+	}
+}
+
+func TestAnalyzeInvalid(t *testing.T) {
+	var invalidTests = []struct {
+		name                string
+		width, height, bins int
+	}{
+		{"negative bins", 4, 4, -1},
+		{"negative width", -1, 4, 4},
+		{"negative size", -4, -4, 4},
+		{"empty", 0, 0, 4},
+	}
+
+	values := make([]float64, 16)
+	for _, tt := range invalidTests {
+		s := Analyze(values, tt.width, tt.height, tt.bins)
+		if s.Mean != 0 || s.StdDev != 0 {
+			t.Errorf("%s: Expected zero stats, got %+v", tt.name, s)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package genesis
+
+import (
+	"math"
+)
+
+// Value is value noise: random values at each lattice point, smoothly interpolated.
+// It is the cheapest Source in the package and the blurriest.
+type Value struct {
+	perm lattice
+}
+
+// NewValueWithSeed returns a Value instance with a 64-bit seed. Two Value instances
+// with the same seed will have the same output.
+func NewValueWithSeed(seed int64) *Value {
+	return &Value{perm: permutation(seed)}
+}
+
+// Eval2 returns a random noise value in two dimensions.
+func (v *Value) Eval2(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int32(xf), int32(yf)
+	u, w := fade(x-xf), fade(y-yf)
+
+	n00 := latticeValue(v.perm.hash2(xi, yi))
+	n10 := latticeValue(v.perm.hash2(xi+1, yi))
+	n01 := latticeValue(v.perm.hash2(xi, yi+1))
+	n11 := latticeValue(v.perm.hash2(xi+1, yi+1))
+
+	return lerp(w, lerp(u, n00, n10), lerp(u, n01, n11))
+}
+
+// Eval3 returns a random noise value in three dimensions.
+func (v *Value) Eval3(x, y, z float64) float64 {
+	xf, yf, zf := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int32(xf), int32(yf), int32(zf)
+	u, w, t := fade(x-xf), fade(y-yf), fade(z-zf)
+
+	n000 := latticeValue(v.perm.hash3(xi, yi, zi))
+	n100 := latticeValue(v.perm.hash3(xi+1, yi, zi))
+	n010 := latticeValue(v.perm.hash3(xi, yi+1, zi))
+	n110 := latticeValue(v.perm.hash3(xi+1, yi+1, zi))
+	n001 := latticeValue(v.perm.hash3(xi, yi, zi+1))
+	n101 := latticeValue(v.perm.hash3(xi+1, yi, zi+1))
+	n011 := latticeValue(v.perm.hash3(xi, yi+1, zi+1))
+	n111 := latticeValue(v.perm.hash3(xi+1, yi+1, zi+1))
+
+	return lerp(t,
+		lerp(w, lerp(u, n000, n100), lerp(u, n010, n110)),
+		lerp(w, lerp(u, n001, n101), lerp(u, n011, n111)),
+	)
+}
+
+// latticeValue maps a permutation entry onto [-1, 1].
+func latticeValue(h int16) float64 {
+	return float64(h)/127.5 - 1
+}
@@ -0,0 +1,121 @@
+package genesis
+
+import (
+	seed "github.com/therealfakemoot/genesis/seed"
+)
+
+// warpOffsets decorrelate the displacement fields for the first three axes of the
+// first two levels of recursion when they are all drawn from the same Source. The
+// values are arbitrary; they only need to be far enough apart that the samples don't
+// overlap. warpOffset derives the rest.
+var warpOffsets = [][4]float64{
+	{0, 0, 0, 0},
+	{5.2, 1.3, 7.1, 2.6},
+	{1.7, 9.2, 3.4, 8.8},
+	{8.3, 2.8, 6.6, 4.9},
+	{4.4, 6.1, 9.9, 0.7},
+	{9.7, 3.9, 1.2, 6.3},
+}
+
+// Warp displaces the coordinates passed to Source by one or more extra noise fields
+// before evaluating it, which turns isotropic blobs into swirling, folded shapes.
+//
+// Each level of recursion samples Field at the coordinates warped by the level
+// before it, so a Depth of 2 gives the classic f(p + s*r(p + s*q(p))) pattern.
+// Warp is deterministic: it holds no state beyond the Sources it wraps.
+type Warp struct {
+	Source Source
+	// Field produces the displacement. Source itself is used when Field is nil.
+	Field    Source
+	Strength float64
+	Depth    int
+}
+
+// NewWarp wraps src with a single level of warping using src as its own
+// displacement field.
+func NewWarp(src Source, strength float64) *Warp {
+	return &Warp{
+		Source:   src,
+		Strength: strength,
+		Depth:    1,
+	}
+}
+
+// warpOffset returns the offset at which level d of the recursion samples the
+// displacement along axis. Every level and axis gets its own: the first two levels
+// keep the fixed table so existing maps are reproducible, and the others are hashed
+// from d and axis into [0, 10) on each coordinate.
+func warpOffset(d, axis int) [4]float64 {
+	if d < 2 && axis < 3 {
+		return warpOffsets[d*3+axis]
+	}
+
+	var o [4]float64
+	h := seed.Mix64(uint64(d)<<2 | uint64(axis))
+	for k := range o {
+		o[k] = float64(h>>(16*uint(k))&0xFFFF) / 0x10000 * 10
+	}
+	return o
+}
+
+func (w *Warp) field() Source {
+	if w.Field == nil {
+		return w.Source
+	}
+	return w.Field
+}
+
+// Eval2 returns the value of Source at the warped position of x,y.
+func (w *Warp) Eval2(x, y float64) float64 {
+	field := w.field()
+	wx, wy := x, y
+
+	for d := 0; d < w.Depth; d++ {
+		ox, oy := warpOffset(d, 0), warpOffset(d, 1)
+
+		dx := field.Eval2(wx+ox[0], wy+ox[1])
+		dy := field.Eval2(wx+oy[0], wy+oy[1])
+		wx, wy = x+w.Strength*dx, y+w.Strength*dy
+	}
+
+	return w.Source.Eval2(wx, wy)
+}
+
+// Eval3 returns the value of Source at the warped position of x,y,z.
+func (w *Warp) Eval3(x, y, z float64) float64 {
+	field := w.field()
+	wx, wy, wz := x, y, z
+
+	for d := 0; d < w.Depth; d++ {
+		ox, oy, oz := warpOffset(d, 0), warpOffset(d, 1), warpOffset(d, 2)
+
+		dx := field.Eval3(wx+ox[0], wy+ox[1], wz+ox[2])
+		dy := field.Eval3(wx+oy[0], wy+oy[1], wz+oy[2])
+		dz := field.Eval3(wx+oz[0], wy+oz[1], wz+oz[2])
+		wx, wy, wz = x+w.Strength*dx, y+w.Strength*dy, z+w.Strength*dz
+	}
+
+	return w.Source.Eval3(wx, wy, wz)
+}
+
+// Eval4 returns the value of Source at the warped position of x,y,z,w. Sources
+// without a fourth dimension are evaluated with Eval3; Is4D reports which applies.
+func (w *Warp) Eval4(x, y, z, t float64) float64 {
+	field := w.field()
+	wx, wy, wz, wt := x, y, z, t
+
+	for d := 0; d < w.Depth; d++ {
+		ox, oy, oz, ot := warpOffset(d, 0), warpOffset(d, 1), warpOffset(d, 2), warpOffset(d, 3)
+
+		dx := eval4(field, wx+ox[0], wy+ox[1], wz+ox[2], wt+ox[3])
+		dy := eval4(field, wx+oy[0], wy+oy[1], wz+oy[2], wt+oy[3])
+		dz := eval4(field, wx+oz[0], wy+oz[1], wz+oz[2], wt+oz[3])
+		dt := eval4(field, wx+ot[0], wy+ot[1], wz+ot[2], wt+ot[3])
+		wx, wy, wz, wt = x+w.Strength*dx, y+w.Strength*dy, z+w.Strength*dz, t+w.Strength*dt
+	}
+
+	return eval4(w.Source, wx, wy, wz, wt)
+}
+
+// Is4D reports whether Source and the displacement field have a fourth dimension.
+func (w *Warp) Is4D() bool { return all4D(w.Source, w.field()) }
@@ -0,0 +1,61 @@
+package genesis
+
+import (
+	"testing"
+)
+
+func TestWarpOffsetsDistinct(t *testing.T) {
+	seen := make(map[[4]float64]bool)
+
+	for d := 0; d < 8; d++ {
+		for axis := 0; axis < 4; axis++ {
+			o := warpOffset(d, axis)
+			if seen[o] {
+				t.Errorf("Expected a distinct offset for level %d, axis %d, got a repeat of %v", d, axis, o)
+			}
+			seen[o] = true
+		}
+	}
+}
+
+func TestWarp(t *testing.T) {
+	build := func(seed int64, strength float64, depth int) *Warp {
+		w := NewWarp(NewFractal(NewWithSeed(seed)), strength)
+		w.Depth = depth
+		return w
+	}
+
+	var points [50][4]float64
+	for i := range points {
+		f := float64(i)
+		points[i] = [4]float64{f * 0.37, f * 0.11, f * 0.23, f * 0.05}
+	}
+
+	// same reports whether a and b agree at every point in 2, 3 and 4 dimensions.
+	same := func(a, b Source4) bool {
+		for _, p := range points {
+			if a.Eval2(p[0], p[1]) != b.Eval2(p[0], p[1]) ||
+				a.Eval3(p[0], p[1], p[2]) != b.Eval3(p[0], p[1], p[2]) ||
+				a.Eval4(p[0], p[1], p[2], p[3]) != b.Eval4(p[0], p[1], p[2], p[3]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !same(build(1, 0.5, 3), build(1, 0.5, 3)) {
+		t.Errorf("Expected the same seed to warp the same way")
+	}
+	if same(build(1, 0.5, 3), build(2, 0.5, 3)) {
+		t.Errorf("Expected different seeds to warp differently")
+	}
+	if !same(build(1, 0, 3), NewFractal(NewWithSeed(1))) {
+		t.Errorf("Expected a strength of 0 to leave the source unwarped")
+	}
+
+	for depth := 2; depth <= 4; depth++ {
+		if same(build(1, 0.5, depth), build(1, 0.5, depth-1)) {
+			t.Errorf("Expected a depth of %d to change the output from a depth of %d", depth, depth-1)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package genesis
+
+import (
+	"fmt"
+	"math"
+
+	seed "github.com/therealfakemoot/genesis/seed"
+)
+
+// Metric is the distance function Worley uses to find the nearest feature points.
+type Metric string
+
+const (
+	// Euclidean distance gives round cells with straight borders.
+	Euclidean Metric = "euclidean"
+	// Manhattan distance gives diamond-shaped cells.
+	Manhattan Metric = "manhattan"
+	// Chebyshev distance gives square, blocky cells.
+	Chebyshev Metric = "chebyshev"
+)
+
+// ParseMetric returns the Metric with the given name.
+func ParseMetric(name string) (Metric, error) {
+	switch m := Metric(name); m {
+	case Euclidean, Manhattan, Chebyshev:
+		return m, nil
+	case "":
+		return Euclidean, nil
+	}
+
+	return "", fmt.Errorf("unknown distance metric %q", name)
+}
+
+// CellReturn selects which distance Worley's Eval methods report.
+type CellReturn string
+
+const (
+	// F1 is the distance to the nearest feature point: round pits or, inverted, domes.
+	F1 CellReturn = "f1"
+	// F2 is the distance to the second nearest feature point.
+	F2 CellReturn = "f2"
+	// F2MinusF1 is zero along the borders between cells, which makes crack and
+	// plate-boundary networks.
+	F2MinusF1 CellReturn = "f2-f1"
+)
+
+// ParseCellReturn returns the CellReturn with the given name.
+func ParseCellReturn(name string) (CellReturn, error) {
+	switch r := CellReturn(name); r {
+	case F1, F2, F2MinusF1:
+		return r, nil
+	case "":
+		return F1, nil
+	}
+
+	return "", fmt.Errorf("unknown cell return %q", name)
+}
+
+// Cell describes the feature points nearest to a sample.
+type Cell struct {
+	// F1 and F2 are the distances to the nearest and second nearest feature points.
+	F1 float64
+	F2 float64
+	// ID identifies the nearest feature point, and therefore the cell the sample
+	// falls in. IDs are stable for a given seed, so they can be used to label cells.
+	ID uint64
+}
+
+// Worley is cellular noise. One feature point is scattered inside every unit cell
+// of the lattice, and the value at a point is derived from its distances to the
+// nearest feature points, rescaled from [0, 1] to [-1, 1].
+//
+// The search covers the 5x5 ( or 5x5x5 ) block of cells around a sample, which finds
+// every feature point within 2 units under any Metric. That always includes F1 for
+// the Euclidean and Chebyshev metrics and for Manhattan in 2D; F2, or F1 under
+// Manhattan in 3D, can very occasionally lie further away and be overestimated.
+type Worley struct {
+	perm   lattice
+	seed   int64
+	Metric Metric
+	Return CellReturn
+}
+
+// worleyReach is how many cells either side of its own a sample's search extends.
+const worleyReach = 2
+
+// NewWorleyWithSeed returns a Worley instance with a 64-bit seed. Two Worley instances
+// with the same seed will have the same cell layout.
+func NewWorleyWithSeed(seed int64) *Worley {
+	return &Worley{
+		perm:   permutation(seed),
+		seed:   seed,
+		Metric: Euclidean,
+		Return: F1,
+	}
+}
+
+// Eval2 returns the cellular noise value in two dimensions.
+func (w *Worley) Eval2(x, y float64) float64 {
+	return w.value(w.Cell2(x, y))
+}
+
+// Eval3 returns the cellular noise value in three dimensions.
+func (w *Worley) Eval3(x, y, z float64) float64 {
+	return w.value(w.Cell3(x, y, z))
+}
+
+// Cell2 returns the nearest feature points to x,y.
+func (w *Worley) Cell2(x, y float64) Cell {
+	xi, yi := int32(math.Floor(x)), int32(math.Floor(y))
+	c := Cell{F1: math.Inf(1), F2: math.Inf(1)}
+
+	// The 3x3 block nearly always holds F1 and F2, so it is searched first; the
+	// outer cells are then only visited when they could hold a closer point.
+	for pass := int32(1); pass <= worleyReach; pass++ {
+		for cy := yi - pass; cy <= yi+pass; cy++ {
+			for cx := xi - pass; cx <= xi+pass; cx++ {
+				if pass > 1 {
+					if ring(cx-xi, cy-yi, 0) != pass || w.distance(gap(cx, x), gap(cy, y), 0) >= c.F2 {
+						continue
+					}
+				}
+
+				h := w.perm.hash2(cx, cy)
+				dx := float64(cx) + w.jitter(h, 0) - x
+				dy := float64(cy) + w.jitter(h, 1) - y
+
+				c.add(w.distance(dx, dy, 0), uint64(uint32(cx))<<32|uint64(uint32(cy)))
+			}
+		}
+	}
+
+	return w.finish(c)
+}
+
+// Cell3 returns the nearest feature points to x,y,z.
+func (w *Worley) Cell3(x, y, z float64) Cell {
+	xi, yi, zi := int32(math.Floor(x)), int32(math.Floor(y)), int32(math.Floor(z))
+	c := Cell{F1: math.Inf(1), F2: math.Inf(1)}
+
+	for pass := int32(1); pass <= worleyReach; pass++ {
+		for cz := zi - pass; cz <= zi+pass; cz++ {
+			for cy := yi - pass; cy <= yi+pass; cy++ {
+				for cx := xi - pass; cx <= xi+pass; cx++ {
+					if pass > 1 {
+						if ring(cx-xi, cy-yi, cz-zi) != pass || w.distance(gap(cx, x), gap(cy, y), gap(cz, z)) >= c.F2 {
+							continue
+						}
+					}
+
+					h := w.perm.hash3(cx, cy, cz)
+					dx := float64(cx) + w.jitter(h, 0) - x
+					dy := float64(cy) + w.jitter(h, 1) - y
+					dz := float64(cz) + w.jitter(h, 2) - z
+
+					id := uint64(uint32(cx)&0x1FFFFF)<<42 | uint64(uint32(cy)&0x1FFFFF)<<21 | uint64(uint32(cz)&0x1FFFFF)
+					c.add(w.distance(dx, dy, dz), id)
+				}
+			}
+		}
+	}
+
+	return w.finish(c)
+}
+
+// ring returns how many cells the offset dx,dy,dz lies from the centre of a search.
+func ring(dx, dy, dz int32) int32 {
+	r := int32(0)
+	for _, d := range [3]int32{dx, dy, dz} {
+		if d < 0 {
+			d = -d
+		}
+		if d > r {
+			r = d
+		}
+	}
+	return r
+}
+
+// gap returns the distance along one axis from v to the unit cell starting at c,
+// which bounds how close any feature point in that cell can be.
+func gap(c int32, v float64) float64 {
+	return math.Max(0, math.Max(float64(c)-v, v-float64(c)-1))
+}
+
+// add records a feature point at distance d, keyed by its packed cell coordinates.
+func (c *Cell) add(d float64, key uint64) {
+	if d < c.F1 {
+		c.F2 = c.F1
+		c.F1 = d
+		c.ID = key
+	} else if d < c.F2 {
+		c.F2 = d
+	}
+}
+
+// finish converts squared Euclidean distances back to real distances and salts the
+// packed cell coordinates with the seed, so different seeds label cells differently.
+func (w *Worley) finish(c Cell) Cell {
+	if w.Metric == Euclidean || w.Metric == "" {
+		c.F1 = math.Sqrt(c.F1)
+		c.F2 = math.Sqrt(c.F2)
+	}
+	c.ID = seed.Mix64(c.ID ^ uint64(w.seed))
+
+	return c
+}
+
+// distance measures the offset dx,dy,dz under w.Metric. Euclidean distances are left
+// squared until finish.
+func (w *Worley) distance(dx, dy, dz float64) float64 {
+	switch w.Metric {
+	case Manhattan:
+		return math.Abs(dx) + math.Abs(dy) + math.Abs(dz)
+	case Chebyshev:
+		return math.Max(math.Abs(dx), math.Max(math.Abs(dy), math.Abs(dz)))
+	}
+
+	return dx*dx + dy*dy + dz*dz
+}
+
+// value reduces c to the distance selected by w.Return, rescaled onto [-1, 1].
+func (w *Worley) value(c Cell) float64 {
+	var d float64
+
+	switch w.Return {
+	case F2:
+		d = c.F2
+	case F2MinusF1:
+		d = c.F2 - c.F1
+	default:
+		d = c.F1
+	}
+
+	return 2*math.Min(d, 1) - 1
+}
+
+// jitter returns the offset along axis of the feature point in the cell hashed to h.
+func (w *Worley) jitter(h int16, axis int16) float64 {
+	return (float64(w.perm[(h+axis*85)&0xFF]) + 0.5) / 256
+}
@@ -0,0 +1,47 @@
+package genesis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// bruteF1 finds the distance to the nearest feature point by searching a block of
+// cells wider than any feature point can be from x,y.
+func bruteF1(w *Worley, x, y float64) float64 {
+	xi, yi := int32(math.Floor(x)), int32(math.Floor(y))
+	f1 := math.Inf(1)
+
+	for cy := yi - 4; cy <= yi+4; cy++ {
+		for cx := xi - 4; cx <= xi+4; cx++ {
+			h := w.perm.hash2(cx, cy)
+			dx := float64(cx) + w.jitter(h, 0) - x
+			dy := float64(cy) + w.jitter(h, 1) - y
+			f1 = math.Min(f1, w.distance(dx, dy, 0))
+		}
+	}
+
+	if w.Metric == Euclidean {
+		f1 = math.Sqrt(f1)
+	}
+	return f1
+}
+
+func TestWorleyF1Exact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, metric := range []Metric{Euclidean, Manhattan, Chebyshev} {
+		w := NewWorleyWithSeed(3)
+		w.Metric = metric
+
+		for i := 0; i < 20000; i++ {
+			// Samples hug the cell edges, where a 3x3 search misses most often.
+			x := float64(r.Intn(64)) + r.Float64()*0.05
+			y := r.Float64() * 64
+
+			if got, want := w.Cell2(x, y).F1, bruteF1(w, x, y); got != want {
+				t.Fatalf("%s: Expected F1 %v at (%v, %v), got %v", metric, want, x, y, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package genesis
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Random is the seed name that asks Parse for a fresh, unpredictable seed.
+const Random = "random"
+
+// Tree derives the seeds for every stage of world generation from a single world
+// seed. Each stage asks for a child by name, such as "terrain/elevation" or
+// "rivers", and gets a seed that depends only on the world seed and that path.
+// Adding, removing or reordering stages therefore never changes the output of the
+// others, which it would if they all drew from one shared generator.
+//
+// Tree is a small value type and safe to copy and share between goroutines.
+type Tree struct {
+	seed int64
+	path string
+}
+
+// New returns the root of the seed tree for a world seed.
+func New(seed int64) Tree {
+	return Tree{seed: seed}
+}
+
+// Parse reads a world seed from its configuration form: either a decimal integer or
+// Random, in which case a seed is drawn from the operating system. The chosen seed is
+// returned either way so the caller can record it and reproduce the world later.
+func Parse(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.EqualFold(s, Random) {
+		var b [8]byte
+		if _, err := crand.Read(b[:]); err != nil {
+			return 0, err
+		}
+		return int64(binary.BigEndian.Uint64(b[:]) >> 1), nil
+	}
+
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("seed must be an integer or %q, got %q", Random, s)
+	}
+
+	return seed, nil
+}
+
+// Seed returns the seed for this node of the tree.
+func (t Tree) Seed() int64 {
+	return t.seed
+}
+
+// Path returns the slash-separated path from the root to this node. The root's path
+// is empty.
+func (t Tree) Path() string {
+	return t.path
+}
+
+// Child returns the node at path below t. Path segments are separated by slashes,
+// so t.Child("terrain/elevation") is the same node as
+// t.Child("terrain").Child("elevation"). Empty segments are ignored.
+func (t Tree) Child(path string) Tree {
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(name))
+
+		t.seed = int64(Mix64(uint64(t.seed) ^ Mix64(h.Sum64())))
+		if t.path == "" {
+			t.path = name
+		} else {
+			t.path += "/" + name
+		}
+	}
+
+	return t
+}
+
+// Rand returns a deterministic random stream seeded from this node.
+func (t Tree) Rand() *Stream {
+	return NewStream(t.seed)
+}
+
+// String formats the node as path=seed, for logging.
+func (t Tree) String() string {
+	return fmt.Sprintf("%s=%d", t.path, t.seed)
+}
+
+// Mix64 is the splitmix64 finalizer. It scatters nearby inputs across the whole
+// 64-bit range, which makes it a cheap hash for packed coordinates and seeds.
+func Mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,80 @@
+package genesis
+
+import (
+	"testing"
+)
+
+func TestChildPaths(t *testing.T) {
+	root := New(18006665432)
+
+	nested := root.Child("terrain").Child("elevation")
+	joined := root.Child("terrain/elevation")
+	if nested != joined {
+		t.Errorf("Expected %v and %v to be the same node", nested, joined)
+	}
+
+	if root.Child("/terrain//elevation/") != joined {
+		t.Errorf("Expected empty path segments to be ignored")
+	}
+
+	if root.Child("") != root {
+		t.Errorf("Expected an empty path to return the root")
+	}
+
+	if joined.Path() != "terrain/elevation" {
+		t.Errorf("Expected path terrain/elevation, got %q", joined.Path())
+	}
+}
+
+func TestChildSeedsDiffer(t *testing.T) {
+	root := New(1)
+	seen := make(map[int64]string)
+
+	for _, p := range []string{"", "terrain", "terrain/elevation", "terrain/moisture", "rivers", "elevation", "elevation/terrain"} {
+		s := root.Child(p).Seed()
+		if other, ok := seen[s]; ok {
+			t.Errorf("Expected %q and %q to have different seeds, both got %d", p, other, s)
+		}
+		seen[s] = p
+	}
+
+	if New(1).Child("rivers").Seed() == New(2).Child("rivers").Seed() {
+		t.Errorf("Expected different world seeds to give different child seeds")
+	}
+}
+
+func TestStreamDeterministic(t *testing.T) {
+	a, b := New(42).Child("features").Rand(), New(42).Child("features").Rand()
+
+	for i := 0; i < 100; i++ {
+		if x, y := a.Uint64(), b.Uint64(); x != y {
+			t.Fatalf("Expected identical streams, got %d and %d at step %d", x, y, i)
+		}
+	}
+
+	c := a.Split()
+	d := b.Split()
+	for i := 0; i < 100; i++ {
+		if x, y := c.Float64(), d.Float64(); x != y || x < 0 || x >= 1 {
+			t.Fatalf("Expected identical split streams in [0, 1), got %v and %v", x, y)
+		}
+	}
+
+	if a.Uint64() == c.Uint64() {
+		t.Errorf("Expected a split stream to diverge from its parent")
+	}
+}
+
+func TestParse(t *testing.T) {
+	if s, err := Parse("18006665432"); err != nil || s != 18006665432 {
+		t.Errorf("Expected 18006665432, got %d, %v", s, err)
+	}
+
+	if _, err := Parse("not a seed"); err == nil {
+		t.Errorf("Expected an error for a non-numeric seed, got nil")
+	}
+
+	if s, err := Parse("Random"); err != nil || s < 0 {
+		t.Errorf("Expected a non-negative random seed, got %d, %v", s, err)
+	}
+}
@@ -0,0 +1,53 @@
+package genesis
+
+// golden is the splitmix64 increment, 2^64 divided by the golden ratio.
+const golden = 0x9e3779b97f4a7c15
+
+// Stream is a splittable splitmix64 random number generator. It implements
+// math/rand.Source64, so rand.New(stream) gives the usual helper methods on top of it.
+//
+// A Stream is not safe for concurrent use. Give each goroutine its own, either from
+// its own Tree node or by calling Split before handing the work out.
+type Stream struct {
+	state uint64
+}
+
+// NewStream returns a Stream seeded with seed.
+func NewStream(seed int64) *Stream {
+	return &Stream{state: uint64(seed)}
+}
+
+// Seed resets the stream to the state NewStream(seed) would have.
+func (s *Stream) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns the next 64 random bits.
+func (s *Stream) Uint64() uint64 {
+	s.state += golden
+	return Mix64(s.state)
+}
+
+// Int63 returns a non-negative random int64.
+func (s *Stream) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Float64 returns a random number in [0, 1).
+func (s *Stream) Float64() float64 {
+	return float64(s.Uint64()>>11) / (1 << 53)
+}
+
+// Intn returns a random number in [0, n). It panics if n <= 0.
+func (s *Stream) Intn(n int) int {
+	if n <= 0 {
+		panic("seed: invalid argument to Intn")
+	}
+	return int(s.Uint64() % uint64(n))
+}
+
+// Split returns a new Stream whose sequence is independent of the rest of s. It
+// advances s by one step, so splitting is itself deterministic.
+func (s *Stream) Split() *Stream {
+	return &Stream{state: Mix64(s.Uint64() ^ golden)}
+}